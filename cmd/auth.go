@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/leotaku/kojirou/cmd/formats/download"
+	"github.com/leotaku/kojirou/mangadex/api"
+)
+
+const (
+	credentialsFilename = "credentials.json"
+	tokenFilename       = "token.json"
+)
+
+// setupAuthentication authenticates all requests to MangaDex if any
+// credentials have been configured, and does nothing otherwise.
+func setupAuthentication() (bool, error) {
+	dir, err := configDir()
+	if err != nil {
+		return false, fmt.Errorf("config: %w", err)
+	}
+
+	credentials, err := getCredentials(dir)
+	if err != nil {
+		return false, fmt.Errorf("credentials: %w", err)
+	} else if credentials == nil {
+		return false, nil
+	}
+	download.SetAuthentication(*credentials, fileTokenStore(filepath.Join(dir, tokenFilename)))
+
+	return true, nil
+}
+
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "kojirou"), nil
+}
+
+// getCredentials reads credentials from the environment, or from the
+// credentials file in the configuration directory otherwise.  Returns
+// nil if no credentials have been configured.
+func getCredentials(dir string) (*api.Credentials, error) {
+	credentials := api.Credentials{
+		Username:     os.Getenv("KOJIROU_USERNAME"),
+		Password:     os.Getenv("KOJIROU_PASSWORD"),
+		ClientID:     os.Getenv("KOJIROU_CLIENT_ID"),
+		ClientSecret: os.Getenv("KOJIROU_CLIENT_SECRET"),
+	}
+
+	if credentials == (api.Credentials{}) {
+		data, err := os.ReadFile(filepath.Join(dir, credentialsFilename))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		} else if err := json.Unmarshal(data, &credentials); err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+	}
+
+	if credentials.Username == "" || credentials.Password == "" || credentials.ClientID == "" || credentials.ClientSecret == "" {
+		return nil, fmt.Errorf("username, password, client ID and client secret are all required")
+	}
+
+	return &credentials, nil
+}
+
+// fileTokenStore stores tokens in a file that is only accessible to
+// the current user.
+type fileTokenStore string
+
+func (f fileTokenStore) Load() (*api.Token, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	token := new(api.Token)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return token, nil
+}
+
+// Save warns about tokens that cannot be saved, such as when the
+// configuration directory is read-only, as these are otherwise
+// silently kept in memory only.
+func (f fileTokenStore) Save(token *api.Token) error {
+	if err := f.save(token); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: token not saved: %v\n", err) //nolint:errcheck
+		return err
+	}
+
+	return nil
+}
+
+func (f fileTokenStore) save(token *api.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(string(f)), 0700); err != nil {
+		return fmt.Errorf("directory: %w", err)
+	} else if err := os.WriteFile(string(f), data, 0600); err != nil {
+		return fmt.Errorf("write: %w", err)
+	} else if err := os.Chmod(string(f), 0600); err != nil {
+		return fmt.Errorf("permissions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leotaku/kojirou/cmd/formats/kindle"
+	md "github.com/leotaku/kojirou/mangadex"
+)
+
+// book is a bundle of volumes or chapters that is written as a single
+// e-book.
+type book struct {
+	manga    md.Manga
+	name     string
+	title    string
+	progress string
+	uniqueID uint32
+}
+
+func (b book) chapters() md.ChapterList {
+	result := make(md.ChapterList, 0)
+	for _, volume := range b.manga.Sorted() {
+		result = append(result, volume.Sorted()...)
+	}
+
+	return result
+}
+
+// identifiers returns the identifiers of all chapters in the book.
+func (b book) identifiers() []string {
+	result := make([]string, 0)
+	for _, chapter := range b.chapters() {
+		result = append(result, chapter.Info.Identifier.String())
+	}
+
+	return result
+}
+
+func bundleBooks(manga md.Manga, bundle BundleArg) []book {
+	switch {
+	case bundle == bundleChapter:
+		return bundleByChapter(manga)
+	case bundle == bundleSeries:
+		if volumes := manga.Sorted(); len(volumes) > 0 {
+			return []book{bundleVolumes(manga, volumes)}
+		} else {
+			return nil
+		}
+	default:
+		result := make([]book, 0)
+		volumes := manga.Sorted()
+		for len(volumes) > 0 {
+			n := int(bundle)
+			if n > len(volumes) {
+				n = len(volumes)
+			}
+			result = append(result, bundleVolumes(manga, volumes[:n]))
+			volumes = volumes[n:]
+		}
+		return result
+	}
+}
+
+func bundleVolumes(manga md.Manga, volumes []md.Volume) book {
+	chapters := make(md.ChapterList, 0)
+	numbers := make([]string, 0)
+	for _, volume := range volumes {
+		chapters = append(chapters, volume.Sorted()...)
+		numbers = append(numbers, volume.Info.Identifier.StringFilled(fillVolumeNumberArg, 0, false))
+	}
+
+	first := volumes[0].Info.Identifier.StringFilled(4, 2, false)
+	last := volumes[len(volumes)-1].Info.Identifier.StringFilled(4, 2, false)
+	name := first
+	if len(volumes) > 1 {
+		name = first + "-" + last
+	}
+
+	return book{
+		manga:    manga.WithChapters(chapters),
+		name:     name,
+		title:    strings.Join(numbers, ", "),
+		progress: fmt.Sprintf("Volume: %v", strings.Join(numbers, ", ")),
+	}
+}
+
+func bundleByChapter(manga md.Manga) []book {
+	result := make([]book, 0)
+	for _, volume := range manga.Sorted() {
+		for _, chapter := range volume.Sorted() {
+			vol, chap := volume.Info.Identifier, chapter.Info.Identifier
+			result = append(result, book{
+				manga:    manga.WithChapters(md.ChapterList{chapter}),
+				name:     vol.StringFilled(4, 2, false) + "_" + chap.StringFilled(4, 2, false),
+				title:    fmt.Sprintf("Chapter %v", chap.StringFilled(fillVolumeNumberArg, 0, false)),
+				progress: fmt.Sprintf("Chapter: %v", chap),
+				uniqueID: kindle.UniqueID(manga.Info.ID, vol.String(), chap.String()),
+			})
+		}
+	}
+
+	return result
+}
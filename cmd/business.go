@@ -2,27 +2,91 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"strings"
 
 	"github.com/leotaku/kojirou/cmd/filter"
 	"github.com/leotaku/kojirou/cmd/formats"
 	"github.com/leotaku/kojirou/cmd/formats/disk"
 	"github.com/leotaku/kojirou/cmd/formats/download"
 	"github.com/leotaku/kojirou/cmd/formats/kindle"
+	"github.com/leotaku/kojirou/cmd/remap"
 	md "github.com/leotaku/kojirou/mangadex"
 	"golang.org/x/text/language"
 )
 
-func run() error {
-	manga, err := download.MangadexSkeleton(identifierArg)
+// authPolicy decides whether requests to MangaDex are authenticated.
+type authPolicy int
+
+const (
+	// Never authenticate, unless required by the flags
+	authNone authPolicy = iota
+	// Authenticate if credentials have been configured
+	authOptional
+	// Always authenticate
+	authRequired
+)
+
+// setup configures downloads from the flags shared by all commands.
+// Requests to MangaDex are only authenticated as required by the
+// given policy and the flags, so that runs which do not need an
+// account never fail on login.
+func setup(policy authPolicy) error {
+	if rateLimitArg <= 0 || atHomeRateLimitArg <= 0 {
+		return fmt.Errorf("rate limits must be positive")
+	} else if chapterJobsArg <= 0 || imageJobsArg <= 0 {
+		return fmt.Errorf("concurrency must be positive")
+	} else if jpegQualityArg < 1 || jpegQualityArg > 100 {
+		return fmt.Errorf("JPEG quality must be between 1 and 100")
+	} else if widepageRatioArg <= 1 {
+		return fmt.Errorf("wide page ratio must be greater than 1")
+	}
+	download.SetRateLimits(rateLimitArg, atHomeRateLimitArg)
+	download.SetConcurrency(chapterJobsArg, imageJobsArg)
+	download.SetReporting(!noReportArg)
+
+	if unreadOnlyArg || markReadArg {
+		policy = authRequired
+	}
+	if policy == authNone {
+		return nil
+	}
+
+	authenticated, err := setupAuthentication()
 	if err != nil {
-		return fmt.Errorf("skeleton: %w", err)
+		return fmt.Errorf("authentication: %w", err)
+	} else if policy == authRequired && !authenticated {
+		return fmt.Errorf("authentication: requires MangaDex credentials")
+	}
+
+	return nil
+}
+
+// runAll generates books for all given manga, each in a separate
+// directory named after its title.  Failures are reported as they
+// happen and do not stop the remaining manga from being generated.
+func runAll(mangaIDs []string) error {
+	failed := make([]string, 0)
+	for _, mangaID := range mangaIDs {
+		if err := run(mangaID, true); err != nil {
+			fmt.Fprintf(os.Stderr, "error: manga %v: %v\n", mangaID, err) //nolint:errcheck
+			failed = append(failed, mangaID)
+		}
 	}
 
-	chapters, err := getChapters(*manga)
+	if len(failed) > 0 {
+		return fmt.Errorf("%v of %v manga failed: %v", len(failed), len(mangaIDs), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func run(mangaID string, nested bool) error {
+	manga, err := getManga(mangaID, dryRunArg)
 	if err != nil {
-		return fmt.Errorf("chapters: %w", err)
+		return err
 	}
-	*manga = manga.WithChapters(chapters)
 
 	formats.PrintSummary(manga)
 	if dryRunArg {
@@ -33,55 +97,150 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("covers: %w", err)
 	}
-	*manga = manga.WithCovers(covers)
+	*manga = manga.WithCovers(covers).WithNearestCovers()
 
-	dir := kindle.NewNormalizedDirectory(outArg, manga.Info.Title, kindleFolderModeArg)
-	for _, volume := range manga.Sorted() {
-		if err := handleVolume(*manga, volume, dir); err != nil {
-			return fmt.Errorf("volume %v: %w", volume.Info.Identifier, err)
+	dir := outputDirectory(*manga, nested)
+	written := make(md.ChapterList, 0)
+	for _, book := range bundleBooks(*manga, bundleArg) {
+		if ok, err := handleBook(*manga, book, dir); err != nil {
+			return fmt.Errorf("book %v: %w", book.name, err)
+		} else if ok {
+			written = append(written, book.chapters()...)
+		}
+	}
+
+	if markReadArg {
+		if err := markRead(manga.Info.ID, written); err != nil {
+			return fmt.Errorf("read markers: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func handleVolume(skeleton md.Manga, volume md.Volume, dir kindle.NormalizedDirectory) error {
-	p := formats.TitledProgress(fmt.Sprintf("Volume: %v", volume.Info.Identifier))
-	if dir.Has(volume.Info.Identifier) && !forceArg {
+// handleBook generates and writes a single book, returning whether it
+// was written or skipped.
+func handleBook(skeleton md.Manga, book book, dir kindle.NormalizedDirectory) (bool, error) {
+	p := formats.TitledProgress(book.progress)
+	if dir.Has(book.name) && !forceArg {
 		p.Cancel("Skipped")
-		return nil
+		return false, nil
 	}
 
-	pages, err := getPages(volume, p)
+	pages, err := kindle.NewPages(kindle.PageOptions{
+		Widepage:    kindle.WidepagePolicy(widepageArg),
+		Autocrop:    autocropArg,
+		LeftToRight: leftToRightArg,
+		AspectRatio: widepageRatioArg,
+		JPEGQuality: jpegQualityArg,
+		LosslessPNG: losslessPNGArg,
+	})
 	if err != nil {
-		return fmt.Errorf("pages: %w", err)
+		return false, fmt.Errorf("pages: %w", err)
+	}
+	defer pages.Close() //nolint:errcheck
+
+	if err := getPages(book.chapters(), pages.Add, p); err != nil {
+		return false, fmt.Errorf("pages: %w", err)
 	}
 
-	mangaForVolume := skeleton.WithChapters(volume.Sorted()).WithPages(pages)
-	mobi := kindle.GenerateMOBI(
-		mangaForVolume,
-		kindle.WidepagePolicy(widepageArg),
-		autocropArg,
-		leftToRightArg,
-	)
+	mobi, err := kindle.GenerateMOBI(book.manga, pages, groupCreditArg)
+	if err != nil {
+		return false, fmt.Errorf("generate: %w", err)
+	}
 	mobi.RightToLeft = !leftToRightArg
-	mobi.Title = fmt.Sprintf("%v: %v",
-		skeleton.Info.Title,
-		volume.Info.Identifier.StringFilled(fillVolumeNumberArg, 0, false),
-	)
+	mobi.Title = fmt.Sprintf("%v: %v", skeleton.Info.Title, book.title)
+	if book.uniqueID != 0 {
+		mobi.UniqueID = book.uniqueID
+	}
 
 	p = formats.VanishingProgress("Writing...")
-	if err := dir.Write(volume.Info.Identifier, mobi, p); err != nil {
+	if err := dir.Write(book.name, mobi, p); err != nil {
+		p.Cancel("Error")
+		return false, fmt.Errorf("write: %w", err)
+	} else if err := dir.Record(book.name, book.identifiers()); err != nil {
 		p.Cancel("Error")
-		return fmt.Errorf("write: %w", err)
+		return false, fmt.Errorf("manifest: %w", err)
 	}
 	p.Done()
 
-	return nil
+	return true, nil
+}
+
+// getManga retrieves the manga with all chapters selected by the
+// flags, but without downloading any pages or covers.  Previews only
+// retrieve the structure of chapters where possible.
+func getManga(mangaID string, preview bool) (*md.Manga, error) {
+	manga, err := download.MangadexSkeleton(mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("skeleton: %w", err)
+	}
+	if titleLanguageArg != "" {
+		titled, ok := manga.WithTitleLanguage(titleLanguageArg)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: no %v title, using \"%v\"\n", titleLanguageArg, manga.Info.Title) //nolint:errcheck
+		}
+		*manga = titled
+	}
+
+	all, err := getChapters(*manga, preview)
+	if err != nil {
+		return nil, fmt.Errorf("chapters: %w", err)
+	}
+	if remapArg != "" {
+		r, err := remap.Load(remapArg)
+		if err != nil {
+			return nil, fmt.Errorf("remap: %w", err)
+		}
+		all = r.Apply(all)
+	}
+	chapters, err := selectChapters(all)
+	if err != nil {
+		return nil, fmt.Errorf("filter: %w", err)
+	}
+	if unreadOnlyArg {
+		read, err := download.MangadexReadMarkers(manga.Info.ID)
+		if err != nil {
+			return nil, fmt.Errorf("read markers: %w", err)
+		}
+		chapters = filter.FilterUnread(chapters, all, read)
+	}
+	inferences, err := getVolumeInferences(*manga, all)
+	if err != nil {
+		return nil, fmt.Errorf("volumes: %w", err)
+	}
+	*manga = manga.WithChapters(chapters, inferences...)
+
+	return manga, nil
+}
+
+func outputDirectory(manga md.Manga, nested bool) kindle.NormalizedDirectory {
+	out := outArg
+	if nested && out != "" && !kindleFolderModeArg {
+		out = path.Join(out, kindle.PathnameFromTitle(manga.Info.Title))
+	}
+
+	return kindle.NewNormalizedDirectory(out, manga.Info.Title, kindleFolderModeArg)
+}
+
+// markRead marks the given chapters of the manga as read, ignoring
+// chapters that were loaded from the filesystem.
+func markRead(mangaID string, chapters md.ChapterList) error {
+	chapterIDs := make([]string, 0)
+	for _, chapter := range chapters {
+		if chapter.Info.GroupNames.String() != "Filesystem" && chapter.Info.ID != "" {
+			chapterIDs = append(chapterIDs, chapter.Info.ID)
+		}
+	}
+	if len(chapterIDs) == 0 {
+		return nil
+	}
+
+	return download.MangadexMarkRead(mangaID, chapterIDs)
 }
 
-func getChapters(manga md.Manga) (md.ChapterList, error) {
-	chapters, err := download.MangadexChapters(manga.Info.ID)
+func getChapters(manga md.Manga, preview bool) (md.ChapterList, error) {
+	chapters, err := getMangadexChapters(manga, preview)
 	if err != nil {
 		return nil, fmt.Errorf("mangadex: %w", err)
 	}
@@ -97,9 +256,42 @@ func getChapters(manga md.Manga) (md.ChapterList, error) {
 		chapters = append(chapters, diskChapters...)
 	}
 
-	chapters, err = filterAndSortFromFlags(chapters)
+	return chapters, nil
+}
+
+// getMangadexChapters retrieves chapters from the full feed, or only
+// their structure from the much smaller aggregate for previews that
+// do not need any information about scantlation groups.
+func getMangadexChapters(manga md.Manga, preview bool) (md.ChapterList, error) {
+	if preview && groupsFilter == "" && rankArg == "most" {
+		chapters, err := download.MangadexAggregate(manga.Info.ID, language.Make(languageArg))
+		if err != nil {
+			return nil, err
+		}
+		// The aggregate folds all unnumbered chapters together, while
+		// books name them after their titles from the feed
+		if !hasUnnumbered(chapters) {
+			return chapters, nil
+		}
+	}
+
+	return download.MangadexChapters(manga.Info.ID)
+}
+
+func hasUnnumbered(chapters md.ChapterList) bool {
+	for _, chapter := range chapters {
+		if chapter.Info.Identifier.IsSpecial() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func selectChapters(chapters md.ChapterList) (md.ChapterList, error) {
+	chapters, err := filterAndSortFromFlags(chapters)
 	if err != nil {
-		return nil, fmt.Errorf("filter: %w", err)
+		return nil, err
 	}
 
 	// Ensure chapters from disk are preferred
@@ -138,24 +330,24 @@ func getCovers(manga *md.Manga) (md.ImageList, error) {
 	return covers, nil
 }
 
-func getPages(volume md.Volume, p formats.CliProgress) (md.ImageList, error) {
-	mangadexPages, err := download.MangadexPages(volume.Sorted().FilterBy(func(ci md.ChapterInfo) bool {
+func getPages(chapters md.ChapterList, sink func(md.Image) error, p formats.CliProgress) error {
+	err := download.MangadexPages(chapters.FilterBy(func(ci md.ChapterInfo) bool {
 		return ci.GroupNames.String() != "Filesystem"
-	}), download.DataSaverPolicy(dataSaverArg), p)
+	}), download.DataSaverPolicy(dataSaverArg), p, sink)
 	if err != nil {
 		p.Cancel("Error")
-		return nil, fmt.Errorf("mangadex: %w", err)
+		return fmt.Errorf("mangadex: %w", err)
 	}
-	diskPages, err := disk.LoadPages(volume.Sorted().FilterBy(func(ci md.ChapterInfo) bool {
+	err = disk.LoadPages(chapters.FilterBy(func(ci md.ChapterInfo) bool {
 		return ci.GroupNames.String() == "Filesystem"
-	}), p)
+	}), p, sink)
 	if err != nil {
 		p.Cancel("Error")
-		return nil, fmt.Errorf("disk: %w", err)
+		return fmt.Errorf("disk: %w", err)
 	}
 	p.Done()
 
-	return append(mangadexPages, diskPages...), nil
+	return nil
 }
 
 func filterAndSortFromFlags(cl md.ChapterList) (md.ChapterList, error) {
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var checkJSONArg bool
+
+var checkCmd = &cobra.Command{
+	Use:   "check [flags..] <identifier>..",
+	Short: "List books that are missing or outdated in the output directory",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if err := rejectUnsupportedFlags(cmd); err != nil {
+			return err
+		} else if len(args) > 1 && diskArg != "" {
+			return fmt.Errorf("--disk: only supported when checking a single manga")
+		} else if err := setup(authNone); err != nil {
+			return err
+		}
+
+		results := make([]checkResult, 0)
+		for _, mangaID := range args {
+			result, err := check(mangaID, len(args) > 1)
+			if err != nil {
+				return fmt.Errorf("manga %v: %w", mangaID, err)
+			} else if len(result.Books) > 0 {
+				results = append(results, *result)
+			}
+		}
+
+		if checkJSONArg {
+			return json.NewEncoder(os.Stdout).Encode(results)
+		}
+		for _, result := range results {
+			for _, book := range result.Books {
+				fmt.Printf("%v: %v is %v (chapters %v)\n", result.Title, book.Title, book.Status, strings.Join(book.Chapters, ", "))
+			}
+		}
+
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
+
+type checkResult struct {
+	ID    string      `json:"id"`
+	Title string      `json:"title"`
+	Books []checkBook `json:"books"`
+}
+
+type checkBook struct {
+	Name     string   `json:"name"`
+	Title    string   `json:"title"`
+	Status   string   `json:"status"`
+	Chapters []string `json:"chapters"`
+}
+
+// check finds all books of the manga that have not been written to
+// the output directory yet, or whose chapters have changed since they
+// were written.  When checking multiple manga, each one is expected in
+// a separate directory named after its title.
+func check(mangaID string, nested bool) (*checkResult, error) {
+	// Previews may differ from the chapters that books are built from
+	manga, err := getManga(mangaID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := outputDirectory(*manga, nested)
+	manifest, err := dir.ReadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	result := checkResult{
+		ID:    manga.Info.ID,
+		Title: manga.Info.Title,
+		Books: make([]checkBook, 0),
+	}
+	for _, book := range bundleBooks(*manga, bundleArg) {
+		status := ""
+		chapters := book.identifiers()
+		if !dir.Has(book.name) {
+			status = "missing"
+		} else if recorded, ok := manifest[book.name]; ok && !sameIdentifiers(recorded, chapters) {
+			status = "changed"
+		} else {
+			continue
+		}
+
+		result.Books = append(result.Books, checkBook{
+			Name:     book.name,
+			Title:    book.title,
+			Status:   status,
+			Chapters: chapters,
+		})
+	}
+
+	return &result, nil
+}
+
+// sameIdentifiers reports whether both lists contain the same
+// identifiers, regardless of their order.
+func sameIdentifiers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[string]int)
+	for _, id := range a {
+		counts[id]++
+	}
+	for _, id := range b {
+		if counts[id] == 0 {
+			return false
+		}
+		counts[id]--
+	}
+
+	return true
+}
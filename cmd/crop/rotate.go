@@ -0,0 +1,34 @@
+package crop
+
+import (
+	"image"
+	"image/color"
+)
+
+type rotated struct {
+	img       image.Image
+	clockwise bool
+}
+
+// Rotate returns a view of the image rotated by 90 degrees.
+func Rotate(img image.Image, clockwise bool) image.Image {
+	return rotated{img, clockwise}
+}
+
+func (r rotated) ColorModel() color.Model {
+	return r.img.ColorModel()
+}
+
+func (r rotated) Bounds() image.Rectangle {
+	size := r.img.Bounds().Size()
+	return image.Rect(0, 0, size.Y, size.X)
+}
+
+func (r rotated) At(x, y int) color.Color {
+	bounds := r.img.Bounds()
+	if r.clockwise {
+		return r.img.At(bounds.Min.X+y, bounds.Max.Y-1-x)
+	} else {
+		return r.img.At(bounds.Max.X-1-y, bounds.Min.Y+x)
+	}
+}
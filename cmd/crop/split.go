@@ -3,22 +3,82 @@ package crop
 import (
 	"fmt"
 	"image"
+	"image/color"
 )
 
-const aspectRatioLimit = 1.2
+const (
+	DefaultAspectRatioLimit = 1.2
 
-func ShouldSplit(img image.Image) bool {
+	// Fraction of the page width around the center that is searched
+	// for a gutter, in each direction.
+	gutterSearchWidth = 0.15
+	// Fraction of dark pixels a column may contain while still being
+	// considered part of the gutter.
+	gutterInkLimit = 0.02
+	// Fraction of the page width a gutter must span at least, so that
+	// single blank columns within a drawing are not mistaken for one.
+	gutterMinWidth = 0.005
+)
+
+func ShouldSplit(img image.Image, limit float64) bool {
 	size := img.Bounds().Size()
-	aspectRatio := float32(size.X) / float32(size.Y)
+	aspectRatio := float64(size.X) / float64(size.Y)
+
+	return aspectRatio > limit
+}
+
+// Gutter finds the horizontal position at which a double page should
+// be split, by searching for the widest low-ink vertical band near
+// the center of the image.  Returns false if no sufficiently wide band
+// exists.
+func Gutter(img image.Image) (int, bool) {
+	bounds := img.Bounds()
+	center := bounds.Min.X + bounds.Dx()/2
+	offset := int(float64(bounds.Dx()) * gutterSearchWidth)
+	from, to := center-offset, center+offset
+
+	bestStart, bestLength := 0, 0
+	runStart, runLength := 0, 0
+	for x := from; x <= to; x++ {
+		if columnInk(img, x) <= gutterInkLimit {
+			if runLength == 0 {
+				runStart = x
+			}
+			runLength++
+		} else {
+			runLength = 0
+		}
 
-	return aspectRatio > aspectRatioLimit
+		// Prefer wider bands, then bands closer to the center
+		if runLength > bestLength || runLength == bestLength && closer(runStart, runLength, bestStart, bestLength, center) {
+			bestStart, bestLength = runStart, runLength
+		}
+	}
+
+	minLength := int(float64(bounds.Dx()) * gutterMinWidth)
+	if minLength < 2 {
+		minLength = 2
+	}
+	if bestLength < minLength {
+		return 0, false
+	}
+
+	return bestStart + bestLength/2, true
 }
 
 func Split(img image.Image) (image.Image, image.Image, error) {
 	bounds := img.Bounds()
+	return SplitAt(img, bounds.Min.X+bounds.Dx()/2)
+}
 
-	left := image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+bounds.Dx()/2, bounds.Max.Y)
-	right := image.Rect(bounds.Min.X+bounds.Dx()/2, bounds.Min.Y, bounds.Max.X, bounds.Max.Y)
+func SplitAt(img image.Image, x int) (image.Image, image.Image, error) {
+	bounds := img.Bounds()
+	if x <= bounds.Min.X || x >= bounds.Max.X {
+		return nil, nil, fmt.Errorf("split position out of bounds: %v", x)
+	}
+
+	left := image.Rect(bounds.Min.X, bounds.Min.Y, x, bounds.Max.Y)
+	right := image.Rect(x, bounds.Min.Y, bounds.Max.X, bounds.Max.Y)
 
 	if img, ok := img.(SubImager); !ok {
 		return nil, nil, fmt.Errorf("image does not support cropping")
@@ -26,3 +86,29 @@ func Split(img image.Image) (image.Image, image.Image, error) {
 		return img.SubImage(left), img.SubImage(right), nil
 	}
 }
+
+func columnInk(img image.Image, x int) float64 {
+	bounds := img.Bounds()
+	dark := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if gray, ok := color.GrayModel.Convert(img.At(x, y)).(color.Gray); ok {
+			if gray.Y <= grayDarknessLimit {
+				dark++
+			}
+		}
+	}
+
+	return float64(dark) / float64(bounds.Dy())
+}
+
+func closer(start, length, otherStart, otherLength, center int) bool {
+	return abs(start+length/2-center) < abs(otherStart+otherLength/2-center)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/leotaku/kojirou/cmd/formats/download"
 	"github.com/leotaku/kojirou/cmd/formats/kindle"
@@ -53,6 +54,10 @@ func (p *WidepagePolicyArg) String() string {
 		return "preserve-and-split"
 	case kindle.WidepagePolicySplitAndPreserve:
 		return "split-and-preserve"
+	case kindle.WidepagePolicyRotate:
+		return "rotate"
+	case kindle.WidepagePolicyRotateAndSplit:
+		return "rotate-and-split"
 	default:
 		panic("unreachable")
 	}
@@ -68,8 +73,12 @@ func (p *WidepagePolicyArg) Set(v string) error {
 		*p = WidepagePolicyArg(kindle.WidepagePolicyPreserveAndSplit)
 	case "split-and-preserve":
 		*p = WidepagePolicyArg(kindle.WidepagePolicySplitAndPreserve)
+	case "rotate":
+		*p = WidepagePolicyArg(kindle.WidepagePolicyRotate)
+	case "rotate-and-split":
+		*p = WidepagePolicyArg(kindle.WidepagePolicyRotateAndSplit)
 	default:
-		return fmt.Errorf(`must be one of: "preserve", "split", or "both"`)
+		return fmt.Errorf(`must be one of: "preserve", "split", "preserve-and-split", "split-and-preserve", "rotate", or "rotate-and-split"`)
 	}
 
 	return nil
@@ -78,3 +87,47 @@ func (p *WidepagePolicyArg) Set(v string) error {
 func (p *WidepagePolicyArg) Type() string {
 	return "wide-page policy"
 }
+
+type BundleArg int
+
+const (
+	bundleSeries  BundleArg = -1
+	bundleChapter BundleArg = 0
+	bundleVolume  BundleArg = 1
+)
+
+func (p *BundleArg) String() string {
+	switch *p {
+	case bundleSeries:
+		return "series"
+	case bundleChapter:
+		return "chapter"
+	case bundleVolume:
+		return "volume"
+	default:
+		return strconv.Itoa(int(*p))
+	}
+}
+
+func (p *BundleArg) Set(v string) error {
+	switch v {
+	case "series":
+		*p = bundleSeries
+	case "chapter":
+		*p = bundleChapter
+	case "volume":
+		*p = bundleVolume
+	default:
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			return fmt.Errorf(`must be one of: "volume", "series", "chapter", or a positive number`)
+		} else {
+			*p = BundleArg(n)
+		}
+	}
+
+	return nil
+}
+
+func (p *BundleArg) Type() string {
+	return "bundle policy"
+}
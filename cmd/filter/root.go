@@ -36,6 +36,38 @@ func FilterByIdentifier(cl md.ChapterList, field string, ranges Ranges) md.Chapt
 	})
 }
 
+// FilterUnread keeps only chapters after the last chapter that has
+// been read, which is determined from the IDs of the read chapters
+// and all known chapters.  Chapters that have been read themselves,
+// in any upload, are always removed, including special chapters.
+func FilterUnread(cl md.ChapterList, all md.ChapterList, readIDs []string) md.ChapterList {
+	read := make(map[string]bool)
+	for _, id := range readIDs {
+		read[id] = true
+	}
+
+	var last *md.Identifier
+	readIdentifiers := make(map[md.Identifier]bool)
+	for _, c := range all {
+		if !read[c.Info.ID] {
+			continue
+		}
+		readIdentifiers[c.Info.Identifier] = true
+		if !c.Info.Identifier.IsSpecial() && (last == nil || last.Less(c.Info.Identifier)) {
+			id := c.Info.Identifier
+			last = &id
+		}
+	}
+
+	return cl.FilterBy(func(ci md.ChapterInfo) bool {
+		if read[ci.ID] || readIdentifiers[ci.Identifier] {
+			return false
+		}
+
+		return last == nil || ci.Identifier.IsSpecial() || last.Less(ci.Identifier)
+	})
+}
+
 func SortByNewest(cl md.ChapterList) md.ChapterList {
 	return cl.SortBy(func(a, b md.ChapterInfo) bool {
 		return a.Published.After(b.Published)
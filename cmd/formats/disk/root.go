@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
@@ -65,37 +66,41 @@ func LoadChapters(directory string, lang language.Tag, p formats.Progress) (md.C
 	return result, nil
 }
 
-func LoadPages(cl md.ChapterList, p formats.Progress) (md.ImageList, error) {
-	result := make(md.ImageList, 0)
+func LoadPages(cl md.ChapterList, p formats.Progress, sink func(md.Image) error) error {
 	for _, chap := range cl {
 		pages, err := os.ReadDir(chap.Info.ID)
 		if err != nil {
-			return nil, fmt.Errorf("list '%v': %w", chap.Info.Identifier, err)
+			return fmt.Errorf("list '%v': %w", chap.Info.Identifier, err)
 		}
 
 		p.Increase(len(pages))
 		for id, page := range pages {
 			p.Add(1)
 
-			f, err := os.Open(path.Join(chap.Info.ID, page.Name()))
+			data, err := os.ReadFile(path.Join(chap.Info.ID, page.Name()))
 			if err != nil {
-				return nil, err
+				return err
 			}
-			img, _, err := image.Decode(f)
+			img, format, err := image.Decode(bytes.NewReader(data))
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			result = append(result, md.Image{
+			err = sink(md.Image{
 				Image:             img,
+				Data:              data,
+				Format:            format,
 				ImageIdentifier:   id,
 				ChapterIdentifier: chap.Info.Identifier,
 				VolumeIdentifier:  chap.Info.VolumeIdentifier,
 			})
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	return result, nil
+	return nil
 }
 
 func LoadCovers(directory string, p formats.Progress) (md.ImageList, error) {
@@ -0,0 +1,52 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	md "github.com/leotaku/kojirou/mangadex"
+)
+
+// failover keeps track of chapters whose at-home server has failed,
+// so that a replacement server is requested only once per chapter.
+type failover struct {
+	mutex    sync.Mutex
+	chapters map[string]*refetched
+}
+
+type refetched struct {
+	once  sync.Once
+	paths md.PathList
+	err   error
+}
+
+func newFailover() *failover {
+	return &failover{
+		chapters: make(map[string]*refetched),
+	}
+}
+
+// refetch returns the path for the same image as the given path, but
+// hosted by a replacement at-home server.
+func (f *failover) refetch(ctx context.Context, path md.Path) (md.Path, error) {
+	f.mutex.Lock()
+	r, ok := f.chapters[path.ChapterID]
+	if !ok {
+		r = new(refetched)
+		f.chapters[path.ChapterID] = r
+	}
+	f.mutex.Unlock()
+
+	r.once.Do(func() {
+		r.paths, r.err = mangadexClient.RefetchPaths(ctx, path)
+	})
+
+	if r.err != nil {
+		return md.Path{}, r.err
+	} else if path.ImageIdentifier >= len(r.paths) {
+		return md.Path{}, fmt.Errorf("image missing from refetched chapter")
+	} else {
+		return r.paths[path.ImageIdentifier], nil
+	}
+}
@@ -10,12 +10,16 @@ import (
 	_ "image/png"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/leotaku/kojirou/cmd/formats"
 	md "github.com/leotaku/kojirou/mangadex"
+	"github.com/leotaku/kojirou/mangadex/api"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/text/language"
 )
 
 type DataSaverPolicy int
@@ -27,34 +31,91 @@ const (
 )
 
 const (
-	maxJobsChapter = 8
-	maxJobsImage   = 16
+	DefaultJobsChapter = 8
+	DefaultJobsImage   = 16
+)
+
+// Reports are best-effort, so they are never retried and given up on
+// quickly
+const reportTimeout = time.Second * 10
+
+var (
+	maxJobsChapter = DefaultJobsChapter
+	maxJobsImage   = DefaultJobsImage
+	reportEnabled  = true
 )
 
 var (
 	httpClient     *http.Client
 	mangadexClient *md.Client
+	reportClient   *md.Client
 )
 
 func init() {
 	retry := retryablehttp.NewClient()
 	retry.Logger = nil
 	retry.RetryWaitMin = time.Second * 5
-	retry.Backoff = retryablehttp.LinearJitterBackoff
+	retry.Backoff = rateLimitBackoff
 	retry.CheckRetry = bodyReadableErrorPolicy
 
 	httpClient = retry.StandardClient()
 	mangadexClient = md.NewClient().WithHTTPClient(httpClient)
+	reportClient = md.NewClient().WithHTTPClient(&http.Client{Timeout: reportTimeout})
+}
+
+// SetRateLimits sets the number of requests per second to the
+// MangaDex API and per minute to its at-home server endpoint.
+func SetRateLimits(global, atHome int) {
+	mangadexClient.WithRateLimits(global, atHome)
+}
+
+// SetAuthentication makes all requests to the MangaDex API on behalf
+// of the user owning the given credentials.
+func SetAuthentication(credentials api.Credentials, store api.TokenStore) {
+	mangadexClient.WithAuthentication(credentials, store)
+}
+
+// SetConcurrency sets the number of chapters and images that are
+// downloaded concurrently.
+func SetConcurrency(chapters, images int) {
+	maxJobsChapter = chapters
+	maxJobsImage = images
+}
+
+// SetReporting enables or disables reporting the outcome of image
+// downloads from at-home servers back to MangaDex.
+func SetReporting(enabled bool) {
+	reportEnabled = enabled
 }
 
 func MangadexSkeleton(mangaID string) (*md.Manga, error) {
 	return mangadexClient.FetchManga(context.TODO(), mangaID)
 }
 
+func MangadexFollows() ([]string, error) {
+	return mangadexClient.FetchFollows(context.TODO())
+}
+
+func MangadexList(listID string) ([]string, error) {
+	return mangadexClient.FetchList(context.TODO(), listID)
+}
+
+func MangadexReadMarkers(mangaID string) ([]string, error) {
+	return mangadexClient.FetchReadMarkers(context.TODO(), mangaID)
+}
+
+func MangadexMarkRead(mangaID string, chapterIDs []string) error {
+	return mangadexClient.MarkRead(context.TODO(), mangaID, chapterIDs)
+}
+
 func MangadexChapters(mangaID string) (md.ChapterList, error) {
 	return mangadexClient.FetchChapters(context.TODO(), mangaID)
 }
 
+func MangadexAggregate(mangaID string, lang language.Tag) (md.ChapterList, error) {
+	return mangadexClient.FetchAggregate(context.TODO(), mangaID, lang)
+}
+
 func MangadexCovers(manga *md.Manga, p formats.Progress) (md.ImageList, error) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -90,7 +151,7 @@ func MangadexCovers(manga *md.Manga, p formats.Progress) (md.ImageList, error) {
 	}
 }
 
-func MangadexPages(chapterList md.ChapterList, policy DataSaverPolicy, p formats.Progress) (md.ImageList, error) {
+func MangadexPages(chapterList md.ChapterList, policy DataSaverPolicy, p formats.Progress, sink func(md.Image) error) error {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
 
@@ -111,16 +172,21 @@ func MangadexPages(chapterList md.ChapterList, policy DataSaverPolicy, p formats
 	images, childEg := pathsToImages(paths, ctx, cancel, policy)
 	eg.Go(childEg.Wait)
 
-	results := make(md.ImageList, 0)
+	sinkErr := error(nil)
 	for image := range images {
 		p.Add(1)
-		results = append(results, image)
+		if sinkErr != nil {
+			continue
+		} else if err := sink(image); err != nil {
+			sinkErr = err
+			cancel()
+		}
 	}
 
-	if err := eg.Wait(); err != nil {
-		return nil, err
+	if err := eg.Wait(); sinkErr != nil {
+		return sinkErr
 	} else {
-		return results, nil
+		return err
 	}
 }
 
@@ -182,6 +248,7 @@ func pathsToImages(
 	ch := make(chan md.Image)
 	eg, ctx := errgroup.WithContext(ctx)
 	eg.SetLimit(maxJobsImage + 1)
+	f := newFailover()
 
 	eg.Go(func() error {
 		for {
@@ -193,7 +260,7 @@ func pathsToImages(
 					return nil
 				}
 				eg.Go(func() error {
-					img, err := getImageWithPolicy(httpClient, ctx, path, policy)
+					img, err := getImageWithFailover(ctx, f, path, policy)
 					if err != nil {
 						defer cancel()
 						return fmt.Errorf("chapter %v: image %v: %w", path.ChapterIdentifier, path.ImageIdentifier, err)
@@ -202,7 +269,7 @@ func pathsToImages(
 					select {
 					case <-ctx.Done():
 						return fmt.Errorf("canceled")
-					case ch <- path.WithImage(img):
+					case ch <- img:
 						return nil
 					}
 				})
@@ -218,31 +285,107 @@ func pathsToImages(
 	return ch, eg
 }
 
-func getImageWithPolicy(client *http.Client, ctx context.Context, path md.Path, policy DataSaverPolicy) (image.Image, error) {
-	resp := new(http.Response)
-	err := error(nil)
+// getImageWithFailover downloads the image for the given path.  If
+// this fails, the image is downloaded again from a different server
+// and only then, depending on the policy, in its lower quality
+// version.
+func getImageWithFailover(ctx context.Context, f *failover, path md.Path, policy DataSaverPolicy) (md.Image, error) {
+	primary := policy
+	if policy == DataSaverPolicyFallback {
+		primary = DataSaverPolicyNo
+	}
+
+	img, err := getImageWithPolicy(httpClient, ctx, path, primary)
+	if err == nil {
+		return img, nil
+	}
 
+	// Only images from at-home servers can be downloaded elsewhere
+	if path.ChapterID != "" {
+		if fresh, ferr := f.refetch(ctx, path); ferr == nil {
+			path = fresh
+			if img, err = getImageWithPolicy(httpClient, ctx, path, primary); err == nil {
+				return img, nil
+			}
+		}
+	}
+
+	if policy == DataSaverPolicyFallback {
+		return getImageWithPolicy(httpClient, ctx, path, DataSaverPolicyPrefer)
+	} else {
+		return md.Image{}, err
+	}
+}
+
+func getImageWithPolicy(client *http.Client, ctx context.Context, path md.Path, policy DataSaverPolicy) (md.Image, error) {
+	url := ""
 	switch policy {
 	case DataSaverPolicyNo, DataSaverPolicyFallback:
-		resp, err = getResp(httpClient, ctx, path.DataURL)
+		url = path.DataURL
 	case DataSaverPolicyPrefer:
-		resp, err = getResp(httpClient, ctx, path.DataSaverURL)
+		url = path.DataSaverURL
 	}
 
+	img, data, format, err := getVerifiedImage(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("download: %w", err)
+		return md.Image{}, err
 	}
 
-	img, _, err := image.Decode(resp.Body)
-	defer resp.Body.Close() //nolint:errcheck
+	return path.WithData(img, data, format), nil
+}
 
-	if err != nil && policy == DataSaverPolicyFallback {
-		return getImageWithPolicy(client, ctx, path, DataSaverPolicyPrefer)
-	} else if err != nil {
-		return nil, fmt.Errorf("decode: %w", err)
-	} else {
-		return img, nil
+// getVerifiedImage downloads and decodes the image at url, retrying
+// once if it does not match the checksum embedded in the file name.
+// Every download is reported exactly once, after it has been verified
+// and decoded.
+func getVerifiedImage(ctx context.Context, url string) (image.Image, []byte, string, error) {
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		resp, err := getResp(httpClient, ctx, url)
+		if err != nil {
+			report(url, nil, false, 0, start)
+			return nil, nil, "", fmt.Errorf("download: %w", err)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:errcheck
+		if err != nil {
+			report(url, resp, false, len(data), start)
+			return nil, nil, "", fmt.Errorf("read: %w", err)
+		}
+
+		if err := verifyChecksum(url, data); err != nil {
+			report(url, resp, false, len(data), start)
+			if attempt >= maxChecksumRetries {
+				return nil, nil, "", err
+			}
+			continue
+		}
+
+		img, format, err := image.Decode(bytes.NewReader(data))
+		report(url, resp, err == nil, len(data), start)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("decode: %w", err)
+		}
+
+		return img, data, format, nil
+	}
+}
+
+// report tells MangaDex how downloading an image from an at-home
+// server went, so that unhealthy servers can be rotated out.  Images
+// hosted by MangaDex itself, such as covers, are never reported.
+// Reports are sent in the background and never delay downloads.
+func report(rawURL string, resp *http.Response, success bool, bytes int, start time.Time) {
+	if !reportEnabled {
+		return
+	} else if u, err := url.Parse(rawURL); err != nil || u.Hostname() == "mangadex.org" || strings.HasSuffix(u.Hostname(), ".mangadex.org") {
+		return
 	}
+
+	cached := resp != nil && strings.HasPrefix(resp.Header.Get("X-Cache"), "HIT")
+	duration := time.Since(start)
+	go reportClient.Report(context.Background(), rawURL, success, cached, bytes, duration) //nolint:errcheck
 }
 
 func getResp(client *http.Client, ctx context.Context, url string) (*http.Response, error) {
@@ -262,6 +405,16 @@ func getResp(client *http.Client, ctx context.Context, url string) (*http.Respon
 	return resp, nil
 }
 
+func rateLimitBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if until, ok := api.RetryAfter(resp); ok {
+			return time.Until(until)
+		}
+	}
+
+	return retryablehttp.LinearJitterBackoff(min, max, attemptNum, resp)
+}
+
 func bodyReadableErrorPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
 	if retry, err := retryablehttp.DefaultRetryPolicy(ctx, resp, err); retry || err != nil {
 		return retry, err
@@ -0,0 +1,43 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const maxChecksumRetries = 1
+
+// verifyChecksum checks data against the SHA-256 hash that at-home
+// servers embed in their file names, such as "1-<hash>.jpg".  Only
+// original quality images are verified, as data-saver images are
+// generated by recompressing them.
+func verifyChecksum(url string, data []byte) error {
+	expected, ok := checksumFromURL(url)
+	if !ok || !strings.Contains(url, "/data/") {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %v, got %v", expected, actual)
+	}
+
+	return nil
+}
+
+func checksumFromURL(url string) (string, bool) {
+	name := path.Base(url)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if idx := strings.LastIndex(name, "-"); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	if _, err := hex.DecodeString(name); err != nil || len(name) != sha256.Size*2 {
+		return "", false
+	}
+
+	return strings.ToLower(name), true
+}
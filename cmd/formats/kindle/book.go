@@ -0,0 +1,129 @@
+package kindle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/leotaku/mobi"
+	"github.com/leotaku/mobi/pdb"
+	"github.com/leotaku/mobi/records"
+	t "github.com/leotaku/mobi/types"
+)
+
+// Placeholder that is passed to the MOBI library for every page, as
+// the actual image records are encoded ahead of time.
+var placeholderImage = image.NewGray(image.Rect(0, 0, 1, 1))
+
+// Book is a MOBI book with its page images already encoded.
+type Book struct {
+	mobi.Book
+	Description string
+	Subjects    []string
+	toc         []tocVolume
+	pages       []spilledPage
+}
+
+// Write writes out the book to w, substituting the encoded page
+// images for the placeholder image records and adding any additional
+// metadata.
+//
+// In contrast to the MOBI library, which renders the complete
+// database in memory, page images are streamed from disk one by one.
+func (b Book) Write(w io.Writer) error {
+	db := b.Book.Realize()
+	null := db.Records[0].(records.NullRecord)
+	first := int(null.MOBIHeader.FirstImageIndex)
+	for i, page := range b.pages {
+		db.ReplaceRecord(first+i, page)
+	}
+
+	// Metadata that is not supported by the MOBI library
+	null.EXTHSection.AddString(t.EXTHDescription, b.Description)
+	null.EXTHSection.AddString(t.EXTHSubject, b.Subjects...)
+	// Books spanning multiple volumes get a nested table of contents
+	if len(b.toc) > 1 {
+		if err := nestNCX(&db, &null, b.toc); err != nil {
+			return fmt.Errorf("table of contents: %w", err)
+		}
+	}
+	db.ReplaceRecord(0, null)
+
+	return writeDatabase(w, db)
+}
+
+type spilledPage struct {
+	filename string
+	size     int
+	format   string
+}
+
+func (s spilledPage) Write(w io.Writer) error {
+	f, err := os.Open(s.filename)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// writeDatabase mirrors pdb.Database.Write, but only renders records
+// to memory when their size is not already known.
+func writeDatabase(w io.Writer, db pdb.Database) error {
+	rendered := make([][]byte, len(db.Records))
+	sizes := make([]int, len(db.Records))
+	for i, rec := range db.Records {
+		if page, ok := rec.(spilledPage); ok {
+			sizes[i] = page.size
+		} else {
+			buf := bytes.NewBuffer(nil)
+			if err := rec.Write(buf); err != nil {
+				return err
+			}
+			rendered[i] = buf.Bytes()
+			sizes[i] = buf.Len()
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	rnum := len(db.Records)
+	header := pdb.NewPalmDBHeader(db.Name, db.Date, uint16(rnum), uint32(rnum)*2-1)
+	if err := binary.Write(bw, pdb.Endian, header); err != nil {
+		return err
+	}
+
+	offset := pdb.PalmDBHeaderLength + pdb.RecordHeaderLength*rnum + 2
+	for i, size := range sizes {
+		h := pdb.RecordHeader{
+			Offset:   uint32(offset),
+			UniqueID: uint16(i * 2),
+		}
+		if err := binary.Write(bw, pdb.Endian, h); err != nil {
+			return err
+		}
+		offset += size
+	}
+
+	// Write 2-byte padding
+	if _, err := bw.Write(make([]byte, 2)); err != nil {
+		return err
+	}
+
+	for i, rec := range db.Records {
+		if rendered[i] != nil {
+			if _, err := bw.Write(rendered[i]); err != nil {
+				return err
+			}
+		} else if err := rec.Write(bw); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
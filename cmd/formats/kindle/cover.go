@@ -0,0 +1,144 @@
+package kindle
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+
+	md "github.com/leotaku/kojirou/mangadex"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	coverWidth  = 1000
+	coverHeight = 1500
+	coverMargin = 100
+)
+
+// mangaToCover returns the cover of the first volume, falling back to
+// the first page of the book or a generated cover if it has none.
+func mangaToCover(manga md.Manga, pages *Pages) image.Image {
+	if cover := manga.Sorted()[0].Cover; cover != nil {
+		return cover
+	} else if cover, err := pages.first(manga); err == nil {
+		return cover
+	} else {
+		return renderCover(manga)
+	}
+}
+
+func (p *Pages) first(manga md.Manga) (image.Image, error) {
+	for _, vol := range manga.Sorted() {
+		for _, chap := range vol.Sorted() {
+			if pages := p.sorted(vol.Info.Identifier, chap.Info.Identifier); len(pages) > 0 {
+				return pages[0].decode()
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no pages")
+}
+
+func (s spilledPage) decode() (image.Image, error) {
+	f, err := os.Open(s.filename)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// renderCover draws a plain cover showing the title, volume numbers
+// and chapter range of the given manga.
+func renderCover(manga md.Manga) image.Image {
+	img := image.NewGray(image.Rect(0, 0, coverWidth, coverHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	volumes := make([]string, 0)
+	for _, id := range manga.Keys() {
+		volumes = append(volumes, id.String())
+	}
+	chapters := make(md.ChapterList, 0)
+	for _, vol := range manga.Sorted() {
+		chapters = append(chapters, vol.Sorted()...)
+	}
+
+	y := coverHeight / 3
+	y = drawLines(img, mustFace(gobold.TTF, 80), manga.Info.Title, y)
+	if keys := manga.Keys(); len(keys) == 1 && keys[0].IsSpecial() {
+		y = drawLines(img, mustFace(goregular.TTF, 56), keys[0].String(), y+80)
+	} else {
+		y = drawLines(img, mustFace(goregular.TTF, 56), "Volume "+strings.Join(volumes, ", "), y+80)
+	}
+	if len(chapters) > 0 {
+		first := chapters[0].Info.Identifier
+		last := chapters[len(chapters)-1].Info.Identifier
+		text := fmt.Sprintf("Chapters %v–%v", first, last)
+		if first.Equal(last) {
+			text = fmt.Sprintf("Chapter %v", first)
+		}
+		drawLines(img, mustFace(goregular.TTF, 56), text, y+40)
+	}
+
+	return img
+}
+
+// drawLines draws the given text centered and wrapped to the cover
+// width, starting at y.  Returns the position below the last line.
+func drawLines(img draw.Image, face font.Face, text string, y int) int {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	height := face.Metrics().Height.Ceil()
+
+	line := ""
+	lines := make([]string, 0)
+	for _, word := range strings.Fields(text) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if line != "" && drawer.MeasureString(candidate).Ceil() > coverWidth-2*coverMargin {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	lines = append(lines, line)
+
+	for _, line := range lines {
+		y += height
+		width := drawer.MeasureString(line)
+		drawer.Dot = fixed.P((coverWidth-width.Ceil())/2, y)
+		drawer.DrawString(line)
+	}
+
+	return y
+}
+
+func mustFace(ttf []byte, size float64) font.Face {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size: size,
+		DPI:  72,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return face
+}
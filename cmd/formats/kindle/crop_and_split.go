@@ -13,19 +13,38 @@ const (
 	WidepagePolicySplit
 	WidepagePolicyPreserveAndSplit
 	WidepagePolicySplitAndPreserve
+	WidepagePolicyRotate
+	WidepagePolicyRotateAndSplit
 )
 
-func cropAndSplit(img image.Image, widepage WidepagePolicy, autocrop bool, ltr bool) []image.Image {
+func cropAndSplit(img image.Image, widepage WidepagePolicy, autocrop bool, ltr bool, aspectRatio float64) []image.Image {
 	if autocrop {
-		croppedImg, err := crop.Crop(img, crop.Bounds(img))
-		if err != nil {
-			panic("unsupported image type for splitting")
+		// Only crop when necessary, so the original image can be reused
+		if bounds := crop.Bounds(img); bounds != img.Bounds() {
+			croppedImg, err := crop.Crop(img, bounds)
+			if err != nil {
+				panic("unsupported image type for splitting")
+			}
+			img = croppedImg
 		}
-		img = croppedImg
 	}
 
-	if widepage != WidepagePolicyPreserve && crop.ShouldSplit(img) {
-		left, right, err := crop.Split(img)
+	if widepage == WidepagePolicyRotate && crop.ShouldSplit(img, aspectRatio) {
+		return []image.Image{rotate(img, ltr)}
+	}
+
+	if widepage != WidepagePolicyPreserve && crop.ShouldSplit(img, aspectRatio) {
+		gutter, ok := crop.Gutter(img)
+		if !ok && widepage == WidepagePolicyRotateAndSplit {
+			return []image.Image{rotate(img, ltr)}
+		} else if !ok && widepage != WidepagePolicySplit {
+			// Keeping the original page is preferable to a bad split
+			return []image.Image{img}
+		} else if !ok {
+			gutter = img.Bounds().Min.X + img.Bounds().Dx()/2
+		}
+
+		left, right, err := crop.SplitAt(img, gutter)
 		if err != nil {
 			panic("unsupported image type for splitting")
 		}
@@ -49,8 +68,20 @@ func cropAndSplit(img image.Image, widepage WidepagePolicy, autocrop bool, ltr b
 			} else {
 				return []image.Image{right, left, img}
 			}
+		case WidepagePolicyRotateAndSplit:
+			if ltr {
+				return []image.Image{rotate(img, ltr), left, right}
+			} else {
+				return []image.Image{rotate(img, ltr), right, left}
+			}
 		}
 	}
 
 	return []image.Image{img}
 }
+
+// Rotate wide pages so that the half which is read first ends up on
+// top, meaning left-to-right pages are rotated clockwise.
+func rotate(img image.Image, ltr bool) image.Image {
+	return crop.Rotate(img, ltr)
+}
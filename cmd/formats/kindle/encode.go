@@ -0,0 +1,86 @@
+package kindle
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	md "github.com/leotaku/kojirou/mangadex"
+	"github.com/leotaku/mobi/jfif"
+)
+
+// JFIF header as written by the MOBI library, which is required for
+// Kindle devices to display JPEG images.
+var naiveJFIFHeader = []byte{
+	0xFF, 0xD8, // SOI
+	0xFF, 0xE0, // APP0 Marker
+	0x00, 0x10, // Length
+	0x4A, 0x46, 0x49, 0x46, 0x00, // JFIF\0
+	0x01, 0x02, // 1.02
+	0x00,       // Density type
+	0x00, 0x01, // X Density
+	0x00, 0x01, // Y Density
+	0x00, 0x00, // No Thumbnail
+}
+
+// encodeOrReuse encodes the given page, unless it is the unmodified
+// source image and the original data can be used as-is.  Returns the
+// encoded data and its format.
+func encodeOrReuse(page image.Image, source md.Image, options PageOptions) ([]byte, string, error) {
+	lossless := options.LosslessPNG && source.Format == "png"
+	switch {
+	case page == source.Image && source.Format == "jpeg" && isSimpleJPEG(source.Data):
+		return withJFIFHeader(source.Data), "jpeg", nil
+	case page == source.Image && lossless:
+		return source.Data, source.Format, nil
+	case lossless:
+		buf := bytes.NewBuffer(nil)
+		if err := png.Encode(buf, page); err != nil {
+			return nil, "", fmt.Errorf("encode: %w", err)
+		}
+		return buf.Bytes(), "png", nil
+	default:
+		buf := bytes.NewBuffer(nil)
+		if err := jfif.Encode(buf, page, &jpeg.Options{Quality: options.JPEGQuality}); err != nil {
+			return nil, "", fmt.Errorf("encode: %w", err)
+		}
+		return buf.Bytes(), "jpeg", nil
+	}
+}
+
+func withJFIFHeader(data []byte) []byte {
+	if len(data) >= 11 && bytes.Equal(data[2:4], naiveJFIFHeader[2:4]) && bytes.Equal(data[6:11], naiveJFIFHeader[6:11]) {
+		return data
+	}
+
+	return append(append([]byte{}, naiveJFIFHeader...), data[2:]...)
+}
+
+// isSimpleJPEG reports whether the given data is a baseline encoded
+// grayscale or YCbCr JPEG, as other variants such as progressive or
+// CMYK images are not supported by all Kindle devices.
+func isSimpleJPEG(data []byte) bool {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return false
+	}
+
+	for i := 2; i+9 < len(data); {
+		if data[i] != 0xFF {
+			return false
+		}
+		marker := data[i+1]
+		length := int(data[i+2])<<8 | int(data[i+3])
+		switch marker {
+		case 0xC0, 0xC1:
+			components := data[i+9]
+			return components == 1 || components == 3
+		case 0xC2, 0xC3, 0xC5, 0xC6, 0xC7, 0xC9, 0xCA, 0xCB, 0xCD, 0xCE, 0xCF, 0xDA:
+			return false
+		}
+		i += 2 + length
+	}
+
+	return false
+}
@@ -11,8 +11,6 @@ import (
 	"strings"
 
 	"github.com/leotaku/kojirou/cmd/formats"
-	md "github.com/leotaku/kojirou/mangadex"
-	"github.com/leotaku/mobi"
 )
 
 type NormalizedDirectory struct {
@@ -24,17 +22,17 @@ func NewNormalizedDirectory(target, title string, kindleFolder bool) NormalizedD
 	switch {
 	case kindleFolder && target == "":
 		return NormalizedDirectory{
-			bookDirectory:      path.Join("kindle", "documents", pathnameFromTitle(title)),
+			bookDirectory:      path.Join("kindle", "documents", PathnameFromTitle(title)),
 			thumbnailDirectory: path.Join("kindle", "system", "thumbnails"),
 		}
 	case kindleFolder:
 		return NormalizedDirectory{
-			bookDirectory:      path.Join(target, "documents", pathnameFromTitle(title)),
+			bookDirectory:      path.Join(target, "documents", PathnameFromTitle(title)),
 			thumbnailDirectory: path.Join(target, "system", "thumbnails"),
 		}
 	case target == "":
 		return NormalizedDirectory{
-			bookDirectory: pathnameFromTitle(title),
+			bookDirectory: PathnameFromTitle(title),
 		}
 	default:
 		return NormalizedDirectory{
@@ -43,23 +41,23 @@ func NewNormalizedDirectory(target, title string, kindleFolder bool) NormalizedD
 	}
 }
 
-func (n *NormalizedDirectory) Has(identifier md.Identifier) bool {
-	filename := identifier.StringFilled(4, 2, false) + ".azw3"
+func (n *NormalizedDirectory) Has(name string) bool {
+	filename := name + ".azw3"
 	return exists(path.Join(n.bookDirectory, filename))
 }
 
-func (n *NormalizedDirectory) Write(identifier md.Identifier, mobi mobi.Book, p formats.Progress) error {
+func (n *NormalizedDirectory) Write(name string, mobi Book, p formats.Progress) error {
 	if n.bookDirectory == "" {
 		return fmt.Errorf("unsupported configuration: no book output")
 	}
-	filename := identifier.StringFilled(4, 2, false) + ".azw3"
+	filename := name + ".azw3"
 
 	f, err := create(path.Join(n.bookDirectory, filename))
 	if err != nil {
 		return fmt.Errorf("create: %w", err)
 	}
 	defer f.Close() //nolint:errcheck
-	if err := mobi.Realize().Write(p.NewProxyWriter(f)); err != nil {
+	if err := mobi.Write(p.NewProxyWriter(f)); err != nil {
 		return fmt.Errorf("write: %w", err)
 	}
 
@@ -77,7 +75,7 @@ func (n *NormalizedDirectory) Write(identifier md.Identifier, mobi mobi.Book, p
 	return nil
 }
 
-func pathnameFromTitle(filename string) string {
+func PathnameFromTitle(filename string) string {
 	switch runtime.GOOS {
 	case "windows":
 		filename = strings.ReplaceAll(filename, "\"", "＂")
@@ -0,0 +1,57 @@
+package kindle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+const manifestFilename = ".kojirou.json"
+
+// Manifest records the chapter identifiers contained in each book of a
+// directory, so that books can later be checked for changed chapters.
+type Manifest map[string][]string
+
+// ReadManifest reads the manifest of the directory, which is empty if
+// no books with recorded chapters have been written yet.
+func (n *NormalizedDirectory) ReadManifest() (Manifest, error) {
+	manifest := make(Manifest)
+	data, err := os.ReadFile(path.Join(n.bookDirectory, manifestFilename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	} else if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Record updates the manifest of the directory with the chapter
+// identifiers of the named book.
+func (n *NormalizedDirectory) Record(name string, chapters []string) error {
+	manifest, err := n.ReadManifest()
+	if err != nil {
+		return err
+	}
+	manifest[name] = chapters
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	f, err := create(path.Join(n.bookDirectory, manifestFilename))
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
@@ -12,11 +12,12 @@ import (
 	"github.com/leotaku/kojirou/mangadex"
 	"github.com/leotaku/mobi"
 	"github.com/leotaku/mobi/records"
+	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
 const (
-	pageTemplateString = `<div>.</div><img src="kindle:embed:{{ . }}?mime=image/jpeg">`
+	pageTemplateString = `<div>.</div><img src="kindle:embed:{{ .ID }}?mime=image/{{ .Format }}">`
 	basePageCSS        = `
 div {
     display: none
@@ -32,51 +33,79 @@ img {
 
 var pageTemplate = template.Must(template.New("page").Parse(pageTemplateString))
 
-func GenerateMOBI(manga mangadex.Manga, widepage WidepagePolicy, crop bool, ltr bool) mobi.Book {
+func GenerateMOBI(manga mangadex.Manga, pages *Pages, groupCredit bool) (Book, error) {
+	if err := pages.Wait(); err != nil {
+		return Book{}, err
+	}
+
 	chapters := make([]mobi.Chapter, 0)
 	images := make([]image.Image, 0)
+	spilled := make([]spilledPage, 0)
 	pageImageIndex := 1
+	toc := mangaToTOC(manga, groupCredit)
 
 	groupNames := make([]string, 0)
-	for _, vol := range manga.Sorted() {
-		for _, chap := range vol.Sorted() {
+	for i, vol := range manga.Sorted() {
+		for j, chap := range vol.Sorted() {
 			groupNames = append(groupNames, chap.Info.GroupNames...)
-			pages := make([]string, 0)
-			for _, img := range chap.Sorted() {
-				images = append(images, cropAndSplit(img, widepage, crop, ltr)...)
-				pages = append(pages, templateToString(pageTemplate, records.To32(pageImageIndex)))
+			chunks := make([]string, 0)
+			for _, page := range pages.sorted(vol.Info.Identifier, chap.Info.Identifier) {
+				images = append(images, placeholderImage)
+				spilled = append(spilled, page)
+				chunks = append(chunks, templateToString(pageTemplate, map[string]string{
+					"ID":     records.To32(pageImageIndex),
+					"Format": page.format,
+				}))
 				pageImageIndex++
 			}
-			title := fmt.Sprintf("%v: %v", chap.Info.Identifier, chap.Info.Title)
 			chapters = append(chapters, mobi.Chapter{
-				Title:  title,
-				Chunks: mobi.Chunks(pages...),
+				Title:  toc[i].Chapters[j],
+				Chunks: mobi.Chunks(chunks...),
 			})
 		}
 	}
 	groupNames = deduplicate(groupNames)
 
-	return mobi.Book{
-		Title:        mangaToTitle(manga),
-		Authors:      manga.Info.Authors,
-		Contributors: groupNames,
-		CreatedDate:  time.Unix(0, 0),
-		Language:     mangaToLanguage(manga),
-		FixedLayout:  true,
-		RightToLeft:  true,
-		CoverImage:   mangaToCover(manga),
-		Images:       images,
-		Chapters:     chapters,
-		CSSFlows:     []string{basePageCSS},
-		UniqueID:     mangaToUniqueID(manga),
-	}
+	return Book{
+		Book: mobi.Book{
+			Title:         mangaToTitle(manga),
+			Authors:       mangaToCreators(manga),
+			Contributors:  groupNames,
+			Publisher:     strings.Join(append([]string{"MangaDex"}, groupNames...), ", "),
+			CreatedDate:   time.Unix(0, 0),
+			PublishedDate: mangaToPublishedDate(manga),
+			Language:      mangaToLanguage(manga),
+			FixedLayout:   true,
+			RightToLeft:   true,
+			CoverImage:    mangaToCover(manga, pages),
+			Images:        images,
+			Chapters:      chapters,
+			CSSFlows:      []string{basePageCSS},
+			UniqueID:      mangaToUniqueID(manga),
+		},
+		Description: manga.Info.Description,
+		Subjects:    mangaToSubjects(manga),
+		toc:         toc,
+		pages:       spilled,
+	}, nil
 }
 
 func mangaToUniqueID(manga mangadex.Manga) uint32 {
-	hash := fnv.New32()
-	hash.Write([]byte(manga.Info.ID))
+	keys := make([]string, 0)
 	for _, idx := range manga.Keys() {
-		hash.Write([]byte(idx.String()))
+		keys = append(keys, idx.String())
+	}
+
+	return UniqueID(manga.Info.ID, keys...)
+}
+
+// UniqueID returns a stable identifier for the book of the given manga
+// that consists of the parts identified by the given keys.
+func UniqueID(mangaID string, keys ...string) uint32 {
+	hash := fnv.New32()
+	hash.Write([]byte(mangaID))
+	for _, key := range keys {
+		hash.Write([]byte(key))
 	}
 
 	return hash.Sum32()
@@ -92,8 +121,40 @@ func mangaToTitle(manga mangadex.Manga) string {
 	return fmt.Sprintf("%v: %v", manga.Info.Title, sn)
 }
 
-func mangaToCover(manga mangadex.Manga) image.Image {
-	return manga.Sorted()[0].Cover
+// MOBI books have no notion of creator roles, so authors and artists
+// are both listed as authors, without listing anyone twice.
+func mangaToCreators(manga mangadex.Manga) []string {
+	seen := make(map[string]bool)
+	creators := make([]string, 0)
+	for _, name := range append(append([]string{}, manga.Info.Authors...), manga.Info.Artists...) {
+		if !seen[name] {
+			seen[name] = true
+			creators = append(creators, name)
+		}
+	}
+
+	return creators
+}
+
+func mangaToPublishedDate(manga mangadex.Manga) time.Time {
+	if manga.Info.Year == 0 {
+		return time.Time{}
+	}
+
+	return time.Date(manga.Info.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MOBI books do not support series metadata, so the demographic and
+// publication status are included as subjects alongside the tags.
+func mangaToSubjects(manga mangadex.Manga) []string {
+	subjects := make([]string, 0)
+	for _, s := range []string{manga.Info.Demographic, manga.Info.Status} {
+		if s != "" {
+			subjects = append(subjects, cases.Title(language.English).String(s))
+		}
+	}
+
+	return append(subjects, manga.Info.Tags...)
 }
 
 func mangaToLanguage(manga mangadex.Manga) language.Tag {
@@ -0,0 +1,216 @@
+package kindle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/leotaku/mobi/pdb"
+	"github.com/leotaku/mobi/records"
+	t "github.com/leotaku/mobi/types"
+)
+
+// The MOBI library only writes a single level of NCX entries, one for
+// every chapter.  Books spanning multiple volumes instead get a
+// two-level index, with volumes as the parents of their chapters.
+
+var tagxNestedNCX = t.TAGXTagTable{
+	t.TAGXTagEntryPosition,
+	t.TAGXTagEntryLength,
+	t.TAGXTagEntryNameOffset,
+	t.TAGXTagEntryDepthLevel,
+	t.TAGXTagEntryParent,
+	t.TAGXTagEntryChild1,
+	t.TAGXTagEntryChildN,
+	t.TAGXTagEnd,
+}
+
+// Only indicates multibyte overlap, without any trailing byte
+// sequences that refer to the single-level index
+const extraDataMultibyte = 0b1
+
+type ncxEntry struct {
+	title  string
+	start  int
+	length int
+}
+
+// nestNCX replaces the single-level NCX index of the database with a
+// two-level index of volumes and chapters.  Kindle devices require
+// entries to be sorted by depth, so all volumes precede all chapters.
+func nestNCX(db *pdb.Database, null *records.NullRecord, toc []tocVolume) error {
+	offset := int(null.MOBIHeader.INDXRecordOffset)
+	flat, ok := db.Records[offset+1].(records.IndexRecord)
+	if !ok {
+		return fmt.Errorf("unexpected NCX record")
+	}
+
+	chapters := make([]ncxEntry, 0)
+	for _, entry := range flat.IDXTEntries {
+		start, length, err := decodeFlatEntry(entry)
+		if err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+		chapters = append(chapters, ncxEntry{start: start, length: length})
+	}
+
+	volumes := make([]ncxEntry, 0)
+	parents := make([]int, 0)
+	firstChildren := make([]int, 0)
+	next := 0
+	for i, vol := range toc {
+		if len(vol.Chapters) == 0 || next+len(vol.Chapters) > len(chapters) {
+			return fmt.Errorf("mismatched chapters")
+		}
+		first, last := chapters[next], chapters[next+len(vol.Chapters)-1]
+		volumes = append(volumes, ncxEntry{
+			title:  vol.Title,
+			start:  first.start,
+			length: last.start + last.length - first.start,
+		})
+		firstChildren = append(firstChildren, len(toc)+next)
+		for _, title := range vol.Chapters {
+			chapters[next].title = title
+			parents = append(parents, i)
+			next++
+		}
+	}
+	if next != len(chapters) {
+		return fmt.Errorf("mismatched chapters")
+	}
+
+	entries := make([][]byte, 0)
+	cncx := make(cncxRecord, 0)
+	for i, vol := range volumes {
+		entries = append(entries, encodeEntry(len(entries), t.CBNCXParent,
+			vol.start, vol.length, cncx.add(vol.title), 0,
+			firstChildren[i], firstChildren[i]+len(toc[i].Chapters)-1,
+		))
+	}
+	for i, chap := range chapters {
+		entries = append(entries, encodeEntry(len(entries), t.CBNCXChild,
+			chap.start, chap.length, cncx.add(chap.title), 1, parents[i],
+		))
+	}
+
+	header := append(encodeINDXString(entryKey(len(entries)-1)), make([]byte, 5)...)
+	pdb.Endian.PutUint16(header[len(header)-5:], uint16(len(entries)))
+	db.ReplaceRecord(offset, records.IndexRecord{
+		TAGXTable:     tagxNestedNCX,
+		Type:          2,
+		IDXTEntries:   [][]byte{header},
+		SubEntryCount: uint32(len(entries)),
+		CNCXCount:     1,
+	})
+	db.ReplaceRecord(offset+1, records.IndexRecord{
+		HeaderType:  1,
+		IDXTEntries: entries,
+	})
+	db.ReplaceRecord(offset+2, cncx)
+
+	return stripTrailingSequences(db, null)
+}
+
+// stripTrailingSequences removes the trailing byte sequences from all
+// text records, as they refer to entries of the single-level index.
+func stripTrailingSequences(db *pdb.Database, null *records.NullRecord) error {
+	remaining := int(null.PalmDocHeader.TextLength)
+	for i := 1; i <= int(null.PalmDocHeader.TextRecordCount); i++ {
+		buf := bytes.NewBuffer(nil)
+		if err := db.Records[i].Write(buf); err != nil {
+			return fmt.Errorf("text: %w", err)
+		}
+
+		length := remaining
+		if length > records.TextRecordMaxSize {
+			length = records.TextRecordMaxSize
+		}
+		remaining -= length
+		// Only the multibyte overlap remains, which is always empty
+		db.ReplaceRecord(i, pdb.RawRecord(append(buf.Bytes()[:length:length], 0)))
+	}
+	null.MOBIHeader.ExtraRecordDataFlags = extraDataMultibyte
+
+	return nil
+}
+
+// decodeFlatEntry returns the text position and length of an entry of
+// the single-level index written by the MOBI library.
+func decodeFlatEntry(entry []byte) (int, int, error) {
+	if len(entry) == 0 || len(entry) < int(entry[0])+2 {
+		return 0, 0, fmt.Errorf("entry too short")
+	}
+	// Skip the label and control byte
+	rest := entry[int(entry[0])+2:]
+
+	start, n := decodeVwi(rest)
+	if n == 0 {
+		return 0, 0, fmt.Errorf("invalid position")
+	}
+	length, m := decodeVwi(rest[n:])
+	if m == 0 {
+		return 0, 0, fmt.Errorf("invalid length")
+	}
+
+	return start, length, nil
+}
+
+func encodeEntry(index int, control byte, values ...int) []byte {
+	result := append(encodeINDXString(entryKey(index)), control)
+	for _, value := range values {
+		result = append(result, encodeVwi(value)...)
+	}
+
+	return result
+}
+
+func entryKey(index int) string {
+	return fmt.Sprintf("%04d", index)
+}
+
+func encodeINDXString(label string) []byte {
+	return append([]byte{byte(len(label))}, label...)
+}
+
+// encodeVwi encodes x as a forward variable-width integer, with the
+// high bit set on its last byte.
+func encodeVwi(x int) []byte {
+	result := []byte{byte(x&0x7f) | 0x80}
+	for x >>= 7; x > 0; x >>= 7 {
+		result = append([]byte{byte(x & 0x7f)}, result...)
+	}
+
+	return result
+}
+
+// decodeVwi decodes a forward variable-width integer, returning its
+// value and encoded length, which is zero if the data is incomplete.
+func decodeVwi(data []byte) (int, int) {
+	value := 0
+	for i, b := range data {
+		value = value<<7 | int(b&0x7f)
+		if b&0x80 != 0 {
+			return value, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+// cncxRecord holds the labels of index entries.
+type cncxRecord []byte
+
+// add appends a label, returning its offset within the record.
+func (c *cncxRecord) add(label string) int {
+	offset := len(*c)
+	*c = append(*c, encodeVwi(len(label))...)
+	*c = append(*c, label...)
+
+	return offset
+}
+
+func (c cncxRecord) Write(w io.Writer) error {
+	pad := make([]byte, (4-len(c)%4)%4)
+	_, err := w.Write(append(append([]byte{}, c...), pad...))
+	return err
+}
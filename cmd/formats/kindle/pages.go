@@ -0,0 +1,146 @@
+package kindle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"sync"
+
+	md "github.com/leotaku/kojirou/mangadex"
+	"golang.org/x/sync/errgroup"
+)
+
+// Pages processes and encodes the pages of a book as they arrive,
+// spilling the encoded images to a temporary directory.  This way,
+// only the images that are currently being processed by one of the
+// workers are held in memory.
+type Pages struct {
+	directory string
+	options   PageOptions
+	eg        *errgroup.Group
+	ctx       context.Context
+
+	mutex  sync.Mutex
+	pages  map[pageKey]map[int][]spilledPage
+	nextID int
+}
+
+// PageOptions configures how pages are processed and encoded.
+type PageOptions struct {
+	Widepage    WidepagePolicy
+	Autocrop    bool
+	LeftToRight bool
+	AspectRatio float64
+	JPEGQuality int
+	LosslessPNG bool
+}
+
+type pageKey struct {
+	volume  md.Identifier
+	chapter md.Identifier
+}
+
+func NewPages(options PageOptions) (*Pages, error) {
+	directory, err := os.MkdirTemp("", "kojirou-")
+	if err != nil {
+		return nil, fmt.Errorf("temporary directory: %w", err)
+	}
+
+	eg, ctx := errgroup.WithContext(context.Background())
+	eg.SetLimit(runtime.NumCPU())
+
+	return &Pages{
+		directory: directory,
+		options:   options,
+		eg:        eg,
+		ctx:       ctx,
+		pages:     make(map[pageKey]map[int][]spilledPage),
+	}, nil
+}
+
+// Add schedules the given image to be processed by the next free
+// worker, blocking until one becomes available.
+func (p *Pages) Add(img md.Image) error {
+	if p.ctx.Err() != nil {
+		return p.eg.Wait()
+	}
+
+	p.eg.Go(func() error {
+		spilled := make([]spilledPage, 0)
+		o := p.options
+		for _, page := range cropAndSplit(img.Image, o.Widepage, o.Autocrop, o.LeftToRight, o.AspectRatio) {
+			data, format, err := encodeOrReuse(page, img, o)
+			if err != nil {
+				return fmt.Errorf("chapter %v: image %v: %w", img.ChapterIdentifier, img.ImageIdentifier, err)
+			}
+			page, err := p.spill(data, format)
+			if err != nil {
+				return fmt.Errorf("chapter %v: image %v: %w", img.ChapterIdentifier, img.ImageIdentifier, err)
+			}
+			spilled = append(spilled, page)
+		}
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		key := pageKey{img.VolumeIdentifier, img.ChapterIdentifier}
+		if _, ok := p.pages[key]; !ok {
+			p.pages[key] = make(map[int][]spilledPage)
+		}
+		p.pages[key][img.ImageIdentifier] = spilled
+
+		return nil
+	})
+
+	return nil
+}
+
+// Wait blocks until all scheduled images have been processed.
+func (p *Pages) Wait() error {
+	return p.eg.Wait()
+}
+
+// Close waits for any remaining workers and removes all spilled
+// images from disk.
+func (p *Pages) Close() error {
+	p.eg.Wait() //nolint:errcheck
+	return os.RemoveAll(p.directory)
+}
+
+func (p *Pages) sorted(volume, chapter md.Identifier) []spilledPage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pages := p.pages[pageKey{volume, chapter}]
+	keys := make([]int, 0)
+	for key := range pages {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	result := make([]spilledPage, 0)
+	for _, key := range keys {
+		result = append(result, pages[key]...)
+	}
+
+	return result
+}
+
+func (p *Pages) spill(data []byte, format string) (spilledPage, error) {
+	p.mutex.Lock()
+	filename := path.Join(p.directory, fmt.Sprintf("%08d", p.nextID))
+	p.nextID++
+	p.mutex.Unlock()
+
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		return spilledPage{}, fmt.Errorf("spill: %w", err)
+	}
+
+	return spilledPage{
+		filename: filename,
+		size:     len(data),
+		format:   format,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package kindle
+
+import (
+	"fmt"
+
+	md "github.com/leotaku/kojirou/mangadex"
+)
+
+// tocVolume is a volume in the table of contents of a book, listing
+// the titles of its chapters in the order they appear in the book.
+type tocVolume struct {
+	Title    string
+	Chapters []string
+}
+
+func mangaToTOC(manga md.Manga, groupCredit bool) []tocVolume {
+	volumes := make([]tocVolume, 0)
+	for _, vol := range manga.Sorted() {
+		chapters := make([]string, 0)
+		for _, chap := range vol.Sorted() {
+			chapters = append(chapters, chapterTitle(chap.Info, groupCredit))
+		}
+		volumes = append(volumes, tocVolume{
+			Title:    volumeTitle(vol.Info),
+			Chapters: chapters,
+		})
+	}
+
+	return volumes
+}
+
+func volumeTitle(info md.VolumeInfo) string {
+	if info.Identifier.IsSpecial() {
+		return info.Identifier.String()
+	} else {
+		return fmt.Sprintf("Volume %v", info.Identifier)
+	}
+}
+
+func chapterTitle(info md.ChapterInfo, groupCredit bool) string {
+	title := fmt.Sprintf("%v: %v", info.Identifier, info.Title)
+	if info.Title == "" || info.Title == info.Identifier.String() {
+		title = info.Identifier.String()
+	}
+	if groupCredit && len(info.GroupNames) > 0 {
+		title = fmt.Sprintf("%v [%v]", title, info.GroupNames)
+	}
+
+	return title
+}
@@ -36,8 +36,12 @@ func PrintSummary(manga *md.Manga) {
 
 	printValue("Title", manga.Info.Title)
 	printValue("Author", manga.Info.Authors)
+	printValue("Artist", manga.Info.Artists)
 	if len(numbers) > 0 {
-		printValue("Groups", strings.Join(groups, ", "))
+		// Chapters may come without any group information
+		if len(groups) > 1 || sorted[0].Info.GroupNames.String() != "" {
+			printValue("Groups", strings.Join(groups, ", "))
+		}
 		printValue("Chapters", strings.Join(numbers, ", "))
 	}
 	if len(discontinuities) > 0 {
@@ -17,7 +17,7 @@ func writeHelp(cmd *cobra.Command, w io.Writer) {
 	groups := make(map[string][]pflag.Flag)
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
 		switch {
-		case f.Hidden:
+		case f.Hidden || isUnsupportedFlag(cmd, f.Name):
 		case strings.HasPrefix(f.Name, "help") || f.Name == "version":
 			groups["3Flags"] = append(groups["3Flags"], *f)
 		case strings.HasSuffix(f.Name, "s"):
@@ -39,7 +39,15 @@ func writeHelp(cmd *cobra.Command, w io.Writer) {
 		return result
 	}
 
-	fmt.Fprintf(w, "Usage:\n  %v\n", cmd.Use) //nolint:errcheck
+	fmt.Fprintf(w, "Usage:\n  %v\n", cmd.CommandPath()+strings.TrimPrefix(cmd.Use, cmd.Name())) //nolint:errcheck
+	if cmd.HasAvailableSubCommands() {
+		fmt.Fprintf(w, "\nCommands:\n") //nolint:errcheck
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				fmt.Fprintf(w, "  %-24v%v\n", sub.Name(), sub.Short) //nolint:errcheck
+			}
+		}
+	}
 	for _, name := range keys(groups) {
 		fmt.Fprintf(w, "\n%v:\n", name[1:]) //nolint:errcheck
 		for _, f := range groups[name] {
@@ -67,3 +75,27 @@ func toSentenceCase(sentence string) string {
 	words[0] = cases.Title(language.English).String(words[0])
 	return strings.Join(words, " ")
 }
+
+// unsupportedFlags lists the inherited flags that have no effect on a
+// command, which are hidden from its help and rejected when given.
+var unsupportedFlags = make(map[*cobra.Command][]string)
+
+func isUnsupportedFlag(cmd *cobra.Command, name string) bool {
+	for _, unsupported := range unsupportedFlags[cmd] {
+		if name == unsupported {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rejectUnsupportedFlags(cmd *cobra.Command) error {
+	for _, name := range unsupportedFlags[cmd] {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%v: not supported by %v", name, cmd.Name())
+		}
+	}
+
+	return nil
+}
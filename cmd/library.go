@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/leotaku/kojirou/cmd/formats/download"
+	"github.com/spf13/cobra"
+)
+
+var followsCmd = &cobra.Command{
+	Use:   "follows [flags..]",
+	Short: "Generate e-books for all manga you follow on MangaDex",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if err := rejectUnsupportedFlags(cmd); err != nil {
+			return err
+		} else if err := setup(authRequired); err != nil {
+			return err
+		}
+
+		mangaIDs, err := download.MangadexFollows()
+		if err != nil {
+			return fmt.Errorf("follows: %w", err)
+		}
+
+		return runAll(mangaIDs)
+	},
+	DisableFlagsInUseLine: true,
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list [flags..] <list-identifier>",
+	Short: "Generate e-books for all manga in a MangaDex custom list",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		// Private lists are only accessible to their owner
+		if err := rejectUnsupportedFlags(cmd); err != nil {
+			return err
+		} else if err := setup(authOptional); err != nil {
+			return err
+		}
+
+		mangaIDs, err := download.MangadexList(args[0])
+		if err != nil {
+			return fmt.Errorf("list: %w", err)
+		}
+
+		return runAll(mangaIDs)
+	},
+	DisableFlagsInUseLine: true,
+}
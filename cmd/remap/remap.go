@@ -0,0 +1,96 @@
+package remap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	md "github.com/leotaku/kojirou/mangadex"
+)
+
+// Remap describes a declarative transformation of a chapter list.
+// All keys refer to the identifiers as they were originally published.
+type Remap struct {
+	// Volumes renumbers volumes
+	Volumes map[md.Identifier]md.Identifier `json:"volumes"`
+	// Chapters moves chapters to different volumes
+	Chapters VolumeMapping `json:"chapters"`
+	// Rename changes chapter identifiers
+	Rename map[md.Identifier]md.Identifier `json:"rename"`
+	// Drop removes chapters
+	Drop []md.Identifier `json:"drop"`
+}
+
+// VolumeMapping maps volume identifiers to lists of chapter
+// identifiers.  It is shared by remap files and volume mapping files.
+type VolumeMapping map[md.Identifier][]md.Identifier
+
+// LoadVolumeMapping reads a volume mapping from a JSON file.
+func LoadVolumeMapping(filename string) (VolumeMapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	m := make(VolumeMapping)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return m, nil
+}
+
+// ByChapter returns the volume of every chapter in the mapping.
+func (m VolumeMapping) ByChapter() map[md.Identifier]md.Identifier {
+	result := make(map[md.Identifier]md.Identifier)
+	for vol, chapters := range m {
+		for _, chap := range chapters {
+			result[chap] = vol
+		}
+	}
+
+	return result
+}
+
+func Load(filename string) (*Remap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	r := new(Remap)
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *Remap) Apply(cl md.ChapterList) md.ChapterList {
+	dropped := make(map[md.Identifier]struct{})
+	for _, id := range r.Drop {
+		dropped[id] = struct{}{}
+	}
+
+	moved := r.Chapters.ByChapter()
+	result := make(md.ChapterList, 0)
+	for _, chap := range cl {
+		chapID, volID := chap.Info.Identifier, chap.Info.VolumeIdentifier
+		if _, ok := dropped[chapID]; ok {
+			continue
+		}
+
+		if id, ok := r.Volumes[volID]; ok {
+			chap.Info.VolumeIdentifier = id
+		}
+		if id, ok := moved[chapID]; ok {
+			chap.Info.VolumeIdentifier = id
+		}
+		if id, ok := r.Rename[chapID]; ok {
+			chap.Info.Identifier = id
+		}
+		result = append(result, chap)
+	}
+
+	return result
+}
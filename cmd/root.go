@@ -1,18 +1,31 @@
 package cmd
 
 import (
+	"image/jpeg"
 	"os"
 	"runtime/pprof"
 
+	"github.com/leotaku/kojirou/cmd/crop"
+	"github.com/leotaku/kojirou/cmd/formats/download"
+	"github.com/leotaku/kojirou/mangadex/api"
 	"github.com/spf13/cobra"
 )
 
 var (
-	identifierArg       string
 	languageArg         string
+	titleLanguageArg    string
 	rankArg             string
 	autocropArg         bool
 	widepageArg         WidepagePolicyArg
+	widepageRatioArg    float64
+	jpegQualityArg      int
+	losslessPNGArg      bool
+	groupCreditArg      bool
+	bundleArg           = bundleVolume
+	volumeInferenceArg  string
+	volumeMappingArg    string
+	volumeChunkingArg   int
+	remapArg            string
 	kindleFolderModeArg bool
 	dryRunArg           bool
 	outArg              string
@@ -21,6 +34,13 @@ var (
 	fillVolumeNumberArg int
 	dataSaverArg        DataSaverPolicyArg
 	diskArg             string
+	rateLimitArg        int
+	atHomeRateLimitArg  int
+	chapterJobsArg      int
+	imageJobsArg        int
+	noReportArg         bool
+	unreadOnlyArg       bool
+	markReadArg         bool
 	cpuprofileArg       string
 	memprofileArg       string
 	groupsFilter        string
@@ -37,9 +57,11 @@ var rootCmd = &cobra.Command{
 	Args:    cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
-		identifierArg = args[0]
+		if err := setup(authNone); err != nil {
+			return err
+		}
 
-		return run()
+		return run(args[0], false)
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if cpuprofileArg != "" {
@@ -167,29 +189,63 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&languageArg, "language", "l", "en", "language for chapter downloads")
-	rootCmd.Flags().StringVarP(&rankArg, "rank", "r", "most", "chapter ranking method to use")
-	rootCmd.Flags().BoolVarP(&autocropArg, "autocrop", "a", false, "crop whitespace from pages automatically")
-	rootCmd.Flags().VarP(&widepageArg, "widepage", "w", "split wide pages automatically")
-	rootCmd.Flags().BoolVarP(&kindleFolderModeArg, "kindle-folder-mode", "k", false, "generate folder structure for Kindle devices")
-	rootCmd.Flags().BoolVarP(&leftToRightArg, "left-to-right", "p", false, "make reading direction left to right")
-	rootCmd.Flags().IntVarP(&fillVolumeNumberArg, "fill-volume-number", "n", 0, "fill volume number with leading zeros in title")
-	rootCmd.Flags().VarP(&dataSaverArg, "data-saver", "s", "download lower quality images to save space")
-	rootCmd.Flags().BoolVarP(&dryRunArg, "dry-run", "d", false, "disable writing of any files")
-	rootCmd.Flags().StringVarP(&outArg, "out", "o", "", "output directory")
-	rootCmd.Flags().BoolVarP(&forceArg, "force", "f", false, "overwrite existing volumes")
-	rootCmd.Flags().StringVarP(&diskArg, "disk", "D", "", "load additional content from disk")
-	rootCmd.Flags().StringVarP(&cpuprofileArg, "cpuprofile", "", "", "write CPU profile to this file")
-	rootCmd.Flags().StringVarP(&memprofileArg, "memprofile", "", "", "write heap profile to this file")
-	rootCmd.Flags().StringVarP(&volumesFilter, "volumes", "V", "", "volume identifiers for chapter downloads")
-	rootCmd.Flags().StringVarP(&chaptersFilter, "chapters", "C", "", "chapter identifiers for chapter downloads")
-	rootCmd.Flags().StringVarP(&groupsFilter, "groups", "G", "", "scantlation groups for chapter downloads")
+	rootCmd.PersistentFlags().StringVarP(&languageArg, "language", "l", "en", "language for chapter downloads")
+	rootCmd.PersistentFlags().StringVarP(&titleLanguageArg, "title-language", "", "", "language of the title, such as en or ja-ro")
+	rootCmd.PersistentFlags().StringVarP(&rankArg, "rank", "r", "most", "chapter ranking method to use")
+	rootCmd.PersistentFlags().BoolVarP(&autocropArg, "autocrop", "a", false, "crop whitespace from pages automatically")
+	rootCmd.PersistentFlags().VarP(&widepageArg, "widepage", "w", "split or rotate wide pages automatically")
+	rootCmd.PersistentFlags().Float64VarP(&widepageRatioArg, "widepage-ratio", "", crop.DefaultAspectRatioLimit, "minimum aspect ratio for wide pages")
+	rootCmd.PersistentFlags().IntVarP(&jpegQualityArg, "jpeg-quality", "", jpeg.DefaultQuality, "quality of pages that need to be encoded as JPEG")
+	rootCmd.PersistentFlags().BoolVarP(&losslessPNGArg, "lossless-png", "", false, "keep PNG pages lossless")
+	rootCmd.PersistentFlags().BoolVarP(&groupCreditArg, "group-credit", "", false, "credit scantlation groups in table of contents")
+	rootCmd.PersistentFlags().VarP(&bundleArg, "bundle", "b", "number of volumes per book, or series or chapter")
+	rootCmd.PersistentFlags().StringVarP(&volumeInferenceArg, "volume-inference", "", "", "infer missing volumes from feed or aggregate")
+	rootCmd.PersistentFlags().StringVarP(&volumeMappingArg, "volume-mapping", "", "", "infer missing volumes from mapping file")
+	rootCmd.PersistentFlags().IntVarP(&volumeChunkingArg, "volume-chunking", "", 0, "infer missing volumes by chunking chapters")
+	rootCmd.PersistentFlags().StringVarP(&remapArg, "remap", "", "", "renumber, move or drop chapters using mapping file")
+	rootCmd.PersistentFlags().BoolVarP(&unreadOnlyArg, "unread-only", "", false, "only download chapters after the last read chapter")
+	rootCmd.PersistentFlags().BoolVarP(&markReadArg, "mark-read", "", false, "mark downloaded chapters as read on MangaDex")
+	rootCmd.PersistentFlags().BoolVarP(&kindleFolderModeArg, "kindle-folder-mode", "k", false, "generate folder structure for Kindle devices")
+	rootCmd.PersistentFlags().BoolVarP(&leftToRightArg, "left-to-right", "p", false, "make reading direction left to right")
+	rootCmd.PersistentFlags().IntVarP(&fillVolumeNumberArg, "fill-volume-number", "n", 0, "fill volume number with leading zeros in title")
+	rootCmd.PersistentFlags().VarP(&dataSaverArg, "data-saver", "s", "download lower quality images to save space")
+	rootCmd.PersistentFlags().BoolVarP(&dryRunArg, "dry-run", "d", false, "disable writing of any files")
+	rootCmd.PersistentFlags().StringVarP(&outArg, "out", "o", "", "output directory")
+	rootCmd.PersistentFlags().BoolVarP(&forceArg, "force", "f", false, "overwrite existing volumes")
+	rootCmd.PersistentFlags().StringVarP(&diskArg, "disk", "D", "", "load additional content from disk")
+	rootCmd.PersistentFlags().IntVarP(&rateLimitArg, "rate-limit", "", api.DefaultGlobalLimit, "maximum API requests per second")
+	rootCmd.PersistentFlags().IntVarP(&atHomeRateLimitArg, "at-home-rate-limit", "", api.DefaultAtHomeLimit, "maximum image server requests per minute")
+	rootCmd.PersistentFlags().IntVarP(&chapterJobsArg, "chapter-concurrency", "", download.DefaultJobsChapter, "number of chapters to download concurrently")
+	rootCmd.PersistentFlags().IntVarP(&imageJobsArg, "image-concurrency", "", download.DefaultJobsImage, "number of images to download concurrently")
+	rootCmd.PersistentFlags().BoolVarP(&noReportArg, "no-report", "", false, "disable reporting of image server health")
+	rootCmd.PersistentFlags().StringVarP(&cpuprofileArg, "cpuprofile", "", "", "write CPU profile to this file")
+	rootCmd.PersistentFlags().StringVarP(&memprofileArg, "memprofile", "", "", "write heap profile to this file")
+	rootCmd.PersistentFlags().StringVarP(&volumesFilter, "volumes", "V", "", "volume identifiers for chapter downloads")
+	rootCmd.PersistentFlags().StringVarP(&chaptersFilter, "chapters", "C", "", "chapter identifiers for chapter downloads")
+	rootCmd.PersistentFlags().StringVarP(&groupsFilter, "groups", "G", "", "scantlation groups for chapter downloads")
 	rootCmd.Flags().BoolVarP(&helpRankingFlag, "help-ranking", "R", false, "Help for chapter ranking")
 	rootCmd.Flags().BoolVarP(&helpFilterFlag, "help-filter", "F", false, "Help for chapter filtering")
 	rootCmd.Flags().SortFlags = false
-	rootCmd.Flags().MarkHidden("cpuprofile") //nolint:errcheck
-	rootCmd.Flags().MarkHidden("memprofile") //nolint:errcheck
-	rootCmd.MarkFlagRequired("language")     //nolint:errcheck
+	rootCmd.PersistentFlags().SortFlags = false
+	rootCmd.PersistentFlags().MarkHidden("cpuprofile") //nolint:errcheck
+	rootCmd.PersistentFlags().MarkHidden("memprofile") //nolint:errcheck
+	rootCmd.MarkPersistentFlagRequired("language")     //nolint:errcheck
+	checkCmd.Flags().BoolVarP(&checkJSONArg, "json", "", false, "print missing and changed books as JSON")
+	followsCmd.Flags().SortFlags = false
+	listCmd.Flags().SortFlags = false
+	checkCmd.Flags().SortFlags = false
+	// Flags that select or remap the chapters of a single series
+	perSeries := []string{"remap", "volume-mapping", "chapters", "volumes", "disk"}
+	unsupportedFlags[followsCmd] = perSeries
+	unsupportedFlags[listCmd] = perSeries
+	// Flags that only affect downloading and writing books
+	unsupportedFlags[checkCmd] = []string{
+		"autocrop", "widepage", "widepage-ratio", "jpeg-quality", "lossless-png", "group-credit",
+		"mark-read", "left-to-right", "data-saver", "dry-run", "force", "chapter-concurrency",
+		"image-concurrency", "at-home-rate-limit", "no-report",
+	}
+	rootCmd.AddCommand(followsCmd, listCmd, checkCmd)
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.SetHelpFunc(help)
 	rootCmd.SetUsageFunc(usage)
 	rootCmd.ParseFlags(os.Args) //nolint:errcheck
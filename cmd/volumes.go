@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leotaku/kojirou/cmd/formats/download"
+	"github.com/leotaku/kojirou/cmd/remap"
+	md "github.com/leotaku/kojirou/mangadex"
+	"golang.org/x/text/language"
+)
+
+func getVolumeInferences(manga md.Manga, all md.ChapterList) ([]md.VolumeInference, error) {
+	inferences := make([]md.VolumeInference, 0)
+	if volumeMappingArg != "" {
+		mapping, err := remap.LoadVolumeMapping(volumeMappingArg)
+		if err != nil {
+			return nil, fmt.Errorf("mapping: %w", err)
+		}
+		inferences = append(inferences, md.InferFromMapping(mapping.ByChapter()))
+	}
+
+	for _, source := range strings.Split(volumeInferenceArg, ",") {
+		switch source {
+		case "":
+		case "feed":
+			inferences = append(inferences, md.InferFromChapters(all))
+		case "aggregate":
+			aggregate, err := download.MangadexAggregate(manga.Info.ID, language.Und)
+			if err != nil {
+				return nil, fmt.Errorf("aggregate: %w", err)
+			}
+			inferences = append(inferences, md.InferFromChapters(aggregate))
+		default:
+			return nil, fmt.Errorf(`not a valid volume inference source: "%v"`, source)
+		}
+	}
+
+	if volumeChunkingArg > 0 {
+		// Chunk all chapters, after applying the other inferences
+		inferred := all
+		for _, infer := range inferences {
+			inferred = infer(inferred)
+		}
+		inferences = append(inferences, md.InferByChunking(inferred, volumeChunkingArg))
+	}
+
+	return inferences, nil
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var AuthURL, _ = url.Parse(`https://auth.mangadex.org/realms/mangadex/protocol/openid-connect/token`)
+
+// Tokens are refreshed this long before they actually expire.
+const tokenExpiryMargin = time.Minute
+
+// Credentials of a MangaDex personal API client.
+type Credentials struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// Token is an access token together with the client and user it was
+// issued for.
+type Token struct {
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token"`
+	Expiry        time.Time `json:"expiry"`
+	RefreshExpiry time.Time `json:"refresh_expiry"`
+	ClientID      string    `json:"client_id"`
+	Username      string    `json:"username"`
+}
+
+// TokenStore persists tokens between sessions, so that logging in
+// again is only required once the refresh token has expired.  Saving
+// is best-effort, as tokens can always be requested again.
+type TokenStore interface {
+	Load() (*Token, error)
+	Save(*Token) error
+}
+
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type authenticator struct {
+	mutex       sync.Mutex
+	credentials Credentials
+	store       TokenStore
+	token       *Token
+	loaded      bool
+}
+
+// WithAuthentication makes the client send authenticated requests on
+// behalf of the user owning the given credentials.
+func (c *Client) WithAuthentication(credentials Credentials, store TokenStore) *Client {
+	c.auth = &authenticator{
+		credentials: credentials,
+		store:       store,
+	}
+	return c
+}
+
+// accessToken returns a valid access token, refreshing the current
+// token or logging in again as required.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	a := c.auth
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// Tokens that cannot be loaded are simply replaced
+	if !a.loaded && a.store != nil {
+		a.token, _ = a.store.Load()
+		a.loaded = true
+	}
+	// Tokens issued for different credentials are never reused
+	if a.token != nil && (a.token.ClientID != a.credentials.ClientID || a.token.Username != a.credentials.Username) {
+		a.token = nil
+	}
+
+	now := time.Now()
+	if a.token != nil && now.Before(a.token.Expiry) {
+		return a.token.AccessToken, nil
+	} else if a.token != nil && now.Before(a.token.RefreshExpiry) {
+		resp, err := c.PostToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {a.token.RefreshToken},
+			"client_id":     {a.credentials.ClientID},
+			"client_secret": {a.credentials.ClientSecret},
+		})
+		if err == nil {
+			return a.update(resp, now), nil
+		}
+	}
+
+	resp, err := c.PostToken(ctx, url.Values{
+		"grant_type":    {"password"},
+		"username":      {a.credentials.Username},
+		"password":      {a.credentials.Password},
+		"client_id":     {a.credentials.ClientID},
+		"client_secret": {a.credentials.ClientSecret},
+	})
+	if err != nil {
+		return "", fmt.Errorf("login: %w", err)
+	}
+
+	return a.update(resp, now), nil
+}
+
+func (a *authenticator) update(resp *TokenResponse, now time.Time) string {
+	a.token = &Token{
+		AccessToken:   resp.AccessToken,
+		RefreshToken:  resp.RefreshToken,
+		Expiry:        now.Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpiryMargin),
+		RefreshExpiry: now.Add(time.Duration(resp.RefreshExpiresIn)*time.Second - tokenExpiryMargin),
+		ClientID:      a.credentials.ClientID,
+		Username:      a.credentials.Username,
+	}
+
+	// Failing to save only means logging in again next session
+	if a.store != nil {
+		a.store.Save(a.token) //nolint:errcheck
+	}
+
+	return a.token.AccessToken
+}
+
+// invalidate marks the current token as rejected, so that the next
+// call to accessToken logs in again.
+func (a *authenticator) invalidate(token string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != nil && a.token.AccessToken == token {
+		a.token = nil
+	}
+}
+
+// PostToken requests a token from the MangaDex authentication server,
+// which does not count towards the API rate limit.
+func (c *Client) PostToken(ctx context.Context, form url.Values) (*TokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", AuthURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("prepare: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	v := new(TokenResponse)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	} else if v.Error != "" {
+		return nil, fmt.Errorf("detail: %v", v.ErrorDescription)
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status: %v", resp.Status)
+	}
+
+	return v, nil
+}
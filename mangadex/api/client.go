@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -13,23 +12,34 @@ import (
 	"go.uber.org/ratelimit"
 )
 
-var (
-	limitGlobal = ratelimit.New(5, ratelimit.Per(time.Second))
-	limitAtHome = ratelimit.New(40, ratelimit.Per(time.Minute))
+const (
+	// Requests per second to any endpoint
+	DefaultGlobalLimit = 5
+	// Requests per minute to the at-home server endpoint
+	DefaultAtHomeLimit = 40
 )
 
 var APIBaseURL, _ = url.Parse(`https://api.mangadex.org/`)
 
+var ReportURL, _ = url.Parse(`https://api.mangadex.network/report`)
+
 type Client struct {
-	http    *http.Client
-	baseURL url.URL
+	http        *http.Client
+	baseURL     url.URL
+	limitGlobal ratelimit.Limiter
+	limitAtHome ratelimit.Limiter
+	backoff     *backoff
+	auth        *authenticator
 }
 
 func NewClient() *Client {
-	return &Client{
+	c := &Client{
 		http:    http.DefaultClient,
 		baseURL: *APIBaseURL,
+		backoff: new(backoff),
 	}
+
+	return c.WithRateLimits(DefaultGlobalLimit, DefaultAtHomeLimit)
 }
 
 func (c *Client) WithBaseURL(url url.URL) *Client {
@@ -42,9 +52,30 @@ func (c *Client) WithHTTPClient(http *http.Client) *Client {
 	return c
 }
 
-func (c *Client) GetManga(ctx context.Context, mangaID string) (*Manga, error) {
+// WithRateLimits sets the number of requests per second to any
+// endpoint and per minute to the at-home server endpoint.
+func (c *Client) WithRateLimits(global, atHome int) *Client {
+	c.limitGlobal = ratelimit.New(global, ratelimit.Per(time.Second))
+	c.limitAtHome = ratelimit.New(atHome, ratelimit.Per(time.Minute))
+	return c
+}
+
+func (c *Client) GetManga(ctx context.Context, mangaID string, args QueryArgs) (*Manga, error) {
 	v := new(Manga)
-	err := c.doJSON(ctx, "GET", "/manga/"+mangaID, v, nil)
+	url := fmt.Sprintf("/manga/%v?%v", mangaID, args.Values().Encode())
+	err := c.doJSON(ctx, "GET", url, v, nil)
+	return v, err
+}
+
+func (c *Client) GetFollows(ctx context.Context, args QueryArgs) (*MangaList, error) {
+	v := new(MangaList)
+	err := c.doJSON(ctx, "GET", "/user/follows/manga?"+args.Values().Encode(), v, nil)
+	return v, err
+}
+
+func (c *Client) GetList(ctx context.Context, listID string) (*CustomList, error) {
+	v := new(CustomList)
+	err := c.doJSON(ctx, "GET", "/list/"+listID, v, nil)
 	return v, err
 }
 
@@ -55,6 +86,29 @@ func (c *Client) GetFeed(ctx context.Context, mangaID string, args QueryArgs) (*
 	return v, err
 }
 
+func (c *Client) GetAggregate(ctx context.Context, mangaID string, args QueryArgs) (*Aggregate, error) {
+	v := new(Aggregate)
+	url := fmt.Sprintf("/manga/%v/aggregate?%v", mangaID, args.Values().Encode())
+	err := c.doJSON(ctx, "GET", url, v, nil)
+	return v, err
+}
+
+func (c *Client) GetReadMarkers(ctx context.Context, mangaID string) (*ReadMarkers, error) {
+	v := new(ReadMarkers)
+	err := c.doJSON(ctx, "GET", "/manga/"+mangaID+"/read", v, nil)
+	return v, err
+}
+
+func (c *Client) PostReadMarkers(ctx context.Context, mangaID string, read, unread []string) (*Result, error) {
+	v := new(Result)
+	err := c.doJSON(ctx, "POST", "/manga/"+mangaID+"/read", v, map[string]interface{}{
+		"chapterIdsRead":   read,
+		"chapterIdsUnread": unread,
+	})
+
+	return v, err
+}
+
 func (c *Client) GetCovers(ctx context.Context, args QueryArgs) (*CoverList, error) {
 	v := new(CoverList)
 	err := c.doJSON(ctx, "GET", "/cover?"+args.Values().Encode(), v, nil)
@@ -73,10 +127,14 @@ func (c *Client) GetGroups(ctx context.Context, args QueryArgs) (*GroupList, err
 	return v, err
 }
 
-func (c *Client) GetAtHome(ctx context.Context, chapterID string) (*AtHome, error) {
+func (c *Client) GetAtHome(ctx context.Context, chapterID string, forcePort443 bool) (*AtHome, error) {
 	v := new(AtHome)
-	limitAtHome.Take()
-	err := c.doJSON(ctx, "GET", "/at-home/server/"+chapterID, v, nil)
+	url := "/at-home/server/" + chapterID
+	if forcePort443 {
+		url += "?forcePort443=true"
+	}
+	c.limitAtHome.Take()
+	err := c.doJSON(ctx, "GET", url, v, nil)
 	return v, err
 }
 
@@ -90,30 +148,49 @@ func (c *Client) PostIDMapping(ctx context.Context, tp string, legacyIDs ...int)
 	return v, err
 }
 
+// PostReport reports the outcome of downloading an image from an
+// at-home server, which does not count towards the API rate limit.
+func (c *Client) PostReport(ctx context.Context, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ReportURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status: %v", resp.Status)
+	}
+
+	return nil
+}
+
 func (c *Client) doJSON(ctx context.Context, method, ref string, result, body interface{}) error {
 	url, err := c.baseURL.Parse(ref)
 	if err != nil {
 		return fmt.Errorf("url: %w", err)
 	}
 
-	rw := io.ReadWriter(nil)
+	data := []byte(nil)
 	if body != nil {
-		rw = bytes.NewBuffer(nil)
-		if err := json.NewEncoder(rw).Encode(body); err != nil {
+		if data, err = json.Marshal(body); err != nil {
 			return fmt.Errorf("encode: %w", err)
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url.String(), rw)
-	if err != nil {
-		return fmt.Errorf("prepare: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	limitGlobal.Take()
-	resp, err := c.http.Do(req)
+	resp, err := c.do(ctx, method, url.String(), data)
 	if err != nil {
-		return fmt.Errorf("do: %w", err)
+		return err
 	}
 	defer resp.Body.Close() //nolint:errcheck
 
@@ -133,3 +210,43 @@ func (c *Client) doJSON(ctx context.Context, method, ref string, result, body in
 
 	return nil
 }
+
+// do sends a request while respecting the rate limits of the client.
+// Requests rejected for exceeding the rate limit are retried by the
+// underlying HTTP client, if at all, while later requests are paused
+// until the rate limit has been reset.  Authenticated requests that
+// are rejected as unauthorized are retried once with a new token.
+func (c *Client) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("prepare: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		token := ""
+		if c.auth != nil {
+			if token, err = c.accessToken(ctx); err != nil {
+				return nil, fmt.Errorf("authenticate: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		if err := c.backoff.wait(ctx); err != nil {
+			return nil, fmt.Errorf("wait: %w", err)
+		}
+		c.limitGlobal.Take()
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("do: %w", err)
+		}
+		c.backoff.update(resp)
+
+		if resp.StatusCode == http.StatusUnauthorized && c.auth != nil && attempt == 0 {
+			resp.Body.Close() //nolint:errcheck
+			c.auth.invalidate(token)
+			continue
+		}
+
+		return resp, nil
+	}
+}
@@ -9,9 +9,14 @@ import (
 type Localized map[string]string
 
 func (l *Localized) UnmarshalJSON(data []byte) error {
-	err := json.Unmarshal(data, (*map[string]string)(l))
+	return unmarshalMapOrEmpty(data, (*map[string]string)(l))
+}
+
+// The MangaDex API encodes empty maps as empty arrays.
+func unmarshalMapOrEmpty(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
 	if err != nil {
-		slice := make([]string, 0)
+		slice := make([]interface{}, 0)
 		if err := json.Unmarshal(data, &slice); err == nil && len(slice) == 0 {
 			return nil
 		}
@@ -26,6 +31,15 @@ type Manga struct {
 	Data     MangaData
 }
 
+type MangaList struct {
+	Result   string
+	Response string
+	Data     []MangaData
+	Limit    int
+	Offset   int
+	Total    int
+}
+
 type MangaData struct {
 	ID         string
 	Type       string
@@ -43,7 +57,7 @@ type MangaData struct {
 		Year                           int
 		ContentRating                  string
 		ChapterNumbersResetOnNewVolume bool
-		Tags                           Relationships
+		Tags                           []TagData
 		State                          string
 		Version                        int
 		CreatedAt                      time.Time
@@ -52,6 +66,17 @@ type MangaData struct {
 	Relationships Relationships
 }
 
+type TagData struct {
+	ID         string
+	Type       string
+	Attributes struct {
+		Name        Localized
+		Description Localized
+		Group       string
+		Version     int
+	}
+}
+
 type ChapterList struct {
 	Result   string
 	Response string
@@ -87,6 +112,36 @@ type ChapterData struct {
 	Relationships Relationships
 }
 
+type Aggregate struct {
+	Result  string
+	Volumes AggregateVolumes
+}
+
+type AggregateVolumes map[string]AggregateVolume
+
+func (a *AggregateVolumes) UnmarshalJSON(data []byte) error {
+	return unmarshalMapOrEmpty(data, (*map[string]AggregateVolume)(a))
+}
+
+type AggregateVolume struct {
+	Volume   string
+	Count    int
+	Chapters AggregateChapters
+}
+
+type AggregateChapters map[string]AggregateChapter
+
+func (a *AggregateChapters) UnmarshalJSON(data []byte) error {
+	return unmarshalMapOrEmpty(data, (*map[string]AggregateChapter)(a))
+}
+
+type AggregateChapter struct {
+	Chapter string
+	ID      string
+	Others  []string
+	Count   int
+}
+
 type CoverList struct {
 	Result   string
 	Response string
@@ -197,6 +252,40 @@ type GroupData struct {
 	Relationships Relationships
 }
 
+type CustomList struct {
+	Result   string
+	Response string
+	Data     CustomListData
+}
+
+type CustomListData struct {
+	ID         string
+	Type       string
+	Attributes struct {
+		Name       string
+		Visibility string
+		Version    int
+	}
+	Relationships Relationships
+}
+
+type ReadMarkers struct {
+	Result string
+	Data   []string
+}
+
+type Result struct {
+	Result string
+}
+
+type Report struct {
+	URL      string `json:"url"`
+	Success  bool   `json:"success"`
+	Cached   bool   `json:"cached"`
+	Bytes    int    `json:"bytes"`
+	Duration int64  `json:"duration"`
+}
+
 type IDMappingList struct {
 	Result   string
 	Response string
@@ -240,15 +329,30 @@ type Relationships struct {
 	Leader     []string
 	Member     []string
 	Creator    []string
+
+	// Expanded contains the complete objects of relationships that
+	// were requested using reference expansion, keyed by their ID.
+	Expanded map[string]json.RawMessage
 }
 
 func (rs *Relationships) UnmarshalJSON(data []byte) error {
-	parsed := make([]Relationship, 0)
-	if err := json.Unmarshal(data, &parsed); err != nil {
+	raw := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 
-	for _, r := range parsed {
+	for _, data := range raw {
+		r := Relationship{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		if len(r.Attributes) > 0 {
+			if rs.Expanded == nil {
+				rs.Expanded = make(map[string]json.RawMessage)
+			}
+			rs.Expanded[r.ID] = data
+		}
+
 		switch r.Type {
 		case "manga":
 			rs.Manga = append(rs.Manga, r.ID)
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Pause used when the server gives no hint about when to retry
+const defaultRetryAfter = time.Minute
+
+// backoff pauses all requests of a client once the server indicates
+// that its rate limit has been exhausted.
+type backoff struct {
+	mutex sync.Mutex
+	until time.Time
+}
+
+func (b *backoff) wait(ctx context.Context) error {
+	b.mutex.Lock()
+	d := time.Until(b.until)
+	b.mutex.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func (b *backoff) update(resp *http.Response) {
+	if until, ok := RetryAfter(resp); ok {
+		b.mutex.Lock()
+		if until.After(b.until) {
+			b.until = until
+		}
+		b.mutex.Unlock()
+	}
+}
+
+// RetryAfter returns the time after which further requests are
+// accepted, if the response indicates that the rate limit has been
+// exhausted.
+func RetryAfter(resp *http.Response) (time.Time, bool) {
+	limited := resp.StatusCode == http.StatusTooManyRequests
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" && !limited {
+		return time.Time{}, false
+	}
+
+	if unix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Retry-After"), 10, 64); err == nil {
+		return time.Unix(unix, 0), true
+	} else if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	} else if limited {
+		return time.Now().Add(defaultRetryAfter), true
+	}
+
+	return time.Time{}, false
+}
@@ -2,11 +2,14 @@ package mangadex
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/leotaku/kojirou/mangadex/api"
+	"golang.org/x/text/language"
 )
 
 var CoverBaseURL, _ = url.Parse("https://uploads.mangadex.org/covers/")
@@ -28,6 +31,32 @@ func (c *Client) WithHTTPClient(http *http.Client) *Client {
 	return c
 }
 
+// WithRateLimits sets the number of requests per second to any
+// endpoint and per minute to the at-home server endpoint.
+func (c *Client) WithRateLimits(global, atHome int) *Client {
+	c.base.WithRateLimits(global, atHome)
+	return c
+}
+
+// WithAuthentication makes the client send authenticated requests on
+// behalf of the user owning the given credentials.
+func (c *Client) WithAuthentication(credentials api.Credentials, store api.TokenStore) *Client {
+	c.base.WithAuthentication(credentials, store)
+	return c
+}
+
+// Report reports the outcome of downloading an image from an at-home
+// server back to MangaDex.
+func (c *Client) Report(ctx context.Context, url string, success, cached bool, bytes int, duration time.Duration) error {
+	return c.base.PostReport(ctx, api.Report{
+		URL:      url,
+		Success:  success,
+		Cached:   cached,
+		Bytes:    bytes,
+		Duration: duration.Milliseconds(),
+	})
+}
+
 func (c *Client) FetchLegacy(ctx context.Context, tp string, legacyID int) (string, error) {
 	mapping, err := c.base.PostIDMapping(ctx, tp, legacyID)
 	if err != nil {
@@ -42,35 +71,82 @@ func (c *Client) FetchLegacy(ctx context.Context, tp string, legacyID int) (stri
 }
 
 func (c *Client) FetchManga(ctx context.Context, mangaID string) (*Manga, error) {
-	base, err := c.base.GetManga(ctx, mangaID)
+	base, err := c.base.GetManga(ctx, mangaID, api.QueryArgs{
+		Includes: []string{"author", "artist"},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("get manga: %w", err)
 	}
 
-	// Only retrieves at most 100 authors
-	authors, err := c.base.GetAuthors(ctx, api.QueryArgs{
-		IDs:   base.Data.Relationships.Author,
-		Limit: 100,
-	})
+	authorIDs := append(append([]string{}, base.Data.Relationships.Author...), base.Data.Relationships.Artist...)
+	authorMap, err := c.fetchAuthorMap(ctx, authorIDs, base.Data.Relationships.Expanded)
 	if err != nil {
 		return nil, fmt.Errorf("get authors: %w", err)
 	}
 
-	// Only retrieves at most 100 artists
-	artists, err := c.base.GetAuthors(ctx, api.QueryArgs{
-		IDs:   base.Data.Relationships.Artist,
-		Limit: 100,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("get artists: %w", err)
-	}
-
 	return &Manga{
-		Info:    convertManga(base, authors, artists),
+		Info:    convertManga(base, authorMap),
 		Volumes: make(map[Identifier]Volume),
 	}, nil
 }
 
+// FetchFollows retrieves the IDs of all manga followed by the
+// authenticated user.
+func (c *Client) FetchFollows(ctx context.Context) ([]string, error) {
+	mangaIDs := make([]string, 0)
+	limit := 100
+	for offset := 0; ; offset += limit {
+		follows, err := c.base.GetFollows(ctx, api.QueryArgs{
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get follows: %w", err)
+		} else {
+			for _, manga := range follows.Data {
+				mangaIDs = append(mangaIDs, manga.ID)
+			}
+		}
+
+		if offset+limit >= follows.Total {
+			break
+		}
+	}
+
+	return mangaIDs, nil
+}
+
+// FetchList retrieves the IDs of all manga in the given custom list.
+func (c *Client) FetchList(ctx context.Context, listID string) ([]string, error) {
+	list, err := c.base.GetList(ctx, listID)
+	if err != nil {
+		return nil, fmt.Errorf("get list: %w", err)
+	}
+
+	return list.Data.Relationships.Manga, nil
+}
+
+// FetchReadMarkers retrieves the IDs of all chapters of the manga that
+// the authenticated user has read.
+func (c *Client) FetchReadMarkers(ctx context.Context, mangaID string) ([]string, error) {
+	markers, err := c.base.GetReadMarkers(ctx, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("get read markers: %w", err)
+	}
+
+	return markers.Data, nil
+}
+
+// MarkRead marks the given chapters of the manga as read by the
+// authenticated user.
+func (c *Client) MarkRead(ctx context.Context, mangaID string, chapterIDs []string) error {
+	if _, err := c.base.PostReadMarkers(ctx, mangaID, chapterIDs, []string{}); err != nil {
+		return fmt.Errorf("post read markers: %w", err)
+	}
+
+	return nil
+}
+
 func (c *Client) FetchChapters(ctx context.Context, mangaID string) (ChapterList, error) {
 	chapters := make([]api.ChapterData, 0)
 
@@ -83,6 +159,7 @@ func (c *Client) FetchChapters(ctx context.Context, mangaID string) (ChapterList
 			EmptyPages:    "0",
 			FuturePublish: "0",
 			ExternalURL:   "0",
+			Includes:      []string{"scanlation_group"},
 		})
 		if err != nil {
 			return nil, fmt.Errorf("get chapters: %w", err)
@@ -103,6 +180,23 @@ func (c *Client) FetchChapters(ctx context.Context, mangaID string) (ChapterList
 	return convertChapters(chapters, groupMap), nil
 }
 
+// FetchAggregate retrieves the volume and chapter structure of the
+// manga without any further chapter information.  If a language is
+// given, only chapters translated into it are included.
+func (c *Client) FetchAggregate(ctx context.Context, mangaID string, lang language.Tag) (ChapterList, error) {
+	args := api.QueryArgs{}
+	if lang != language.Und {
+		args.Languages = []language.Tag{lang}
+	}
+
+	aggregate, err := c.base.GetAggregate(ctx, mangaID, args)
+	if err != nil {
+		return nil, fmt.Errorf("get aggregate: %w", err)
+	}
+
+	return convertAggregate(aggregate, lang), nil
+}
+
 func (c *Client) FetchCovers(ctx context.Context, mangaID string) (PathList, error) {
 	covers := make([]api.CoverData, 0)
 	limit := 100
@@ -127,7 +221,24 @@ func (c *Client) FetchCovers(ctx context.Context, mangaID string) (PathList, err
 }
 
 func (c *Client) FetchPaths(ctx context.Context, chapter *Chapter) (PathList, error) {
-	ah, err := c.base.GetAtHome(ctx, chapter.Info.ID)
+	return c.fetchPaths(ctx, chapter, false)
+}
+
+// RefetchPaths fetches the paths for the chapter of the given path
+// again, from a server that is reachable using the standard HTTPS
+// port.  This is useful when the original server is unreliable.
+func (c *Client) RefetchPaths(ctx context.Context, path Path) (PathList, error) {
+	return c.fetchPaths(ctx, &Chapter{
+		Info: ChapterInfo{
+			ID:               path.ChapterID,
+			Identifier:       path.ChapterIdentifier,
+			VolumeIdentifier: path.VolumeIdentifier,
+		},
+	}, true)
+}
+
+func (c *Client) fetchPaths(ctx context.Context, chapter *Chapter, forcePort443 bool) (PathList, error) {
+	ah, err := c.base.GetAtHome(ctx, chapter.Info.ID, forcePort443)
 	if err != nil {
 		return nil, fmt.Errorf("get at home: %w", err)
 	} else if len(ah.Chapter.Data) != len(ah.Chapter.DataSaver) {
@@ -138,38 +249,100 @@ func (c *Client) FetchPaths(ctx context.Context, chapter *Chapter) (PathList, er
 }
 
 func (c *Client) fetchGroupMap(ctx context.Context, chapters []api.ChapterData) (map[string]api.GroupData, error) {
-	dedup := make(map[string]struct{})
-	groupIDs := make([]string, 0)
+	ids := make([]string, 0)
+	expanded := make(map[string]json.RawMessage)
 	for _, chap := range chapters {
-		for _, id := range chap.Relationships.Group {
-			if _, ok := dedup[id]; !ok {
-				groupIDs = append(groupIDs, id)
-				dedup[id] = struct{}{}
-			}
+		ids = append(ids, chap.Relationships.Group...)
+		for id, raw := range chap.Relationships.Expanded {
+			expanded[id] = raw
 		}
 	}
 
 	result := make(map[string]api.GroupData)
-	limit := 100
-	for offset := 0; offset < len(groupIDs); offset += limit {
-		// Always send at most `limit` IDs
-		end := len(groupIDs)
-		if end > offset+limit {
-			end = offset + limit
+	err := fetchReferenced(ids, expanded, func(id string, raw json.RawMessage) error {
+		group := api.GroupData{}
+		if err := json.Unmarshal(raw, &group); err != nil {
+			return err
 		}
-
+		result[id] = group
+		return nil
+	}, func(ids []string, limit int) error {
 		gs, err := c.base.GetGroups(ctx, api.QueryArgs{
-			IDs:   groupIDs[offset:end],
+			IDs:   ids,
+			Limit: limit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, group := range gs.Data {
+			result[group.ID] = group
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (c *Client) fetchAuthorMap(ctx context.Context, ids []string, expanded map[string]json.RawMessage) (map[string]api.AuthorData, error) {
+	result := make(map[string]api.AuthorData)
+	err := fetchReferenced(ids, expanded, func(id string, raw json.RawMessage) error {
+		author := api.AuthorData{}
+		if err := json.Unmarshal(raw, &author); err != nil {
+			return err
+		}
+		result[id] = author
+		return nil
+	}, func(ids []string, limit int) error {
+		as, err := c.base.GetAuthors(ctx, api.QueryArgs{
+			IDs:   ids,
 			Limit: limit,
 		})
 		if err != nil {
-			return nil, err
+			return err
+		}
+		for _, author := range as.Data {
+			result[author.ID] = author
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// fetchReferenced resolves the referenced objects with the given IDs.
+// Objects included using reference expansion are preferred and passed
+// to decode, while all others are passed to fetch in batches.
+func fetchReferenced(ids []string, expanded map[string]json.RawMessage, decode func(string, json.RawMessage) error, fetch func([]string, int) error) error {
+	dedup := make(map[string]struct{})
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if _, ok := dedup[id]; ok {
+			continue
 		} else {
-			for _, group := range gs.Data {
-				result[group.ID] = group
+			dedup[id] = struct{}{}
+		}
+
+		if raw, ok := expanded[id]; ok {
+			if err := decode(id, raw); err != nil {
+				return err
 			}
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	limit := 100
+	for offset := 0; offset < len(missing); offset += limit {
+		// Always send at most `limit` IDs
+		end := len(missing)
+		if end > offset+limit {
+			end = offset + limit
+		}
+
+		if err := fetch(missing[offset:end], limit); err != nil {
+			return err
 		}
 	}
 
-	return result, nil
+	return nil
 }
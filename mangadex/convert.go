@@ -3,28 +3,45 @@ package mangadex
 import (
 	"image"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/leotaku/kojirou/mangadex/api"
 	"golang.org/x/text/language"
 )
 
-func convertManga(b *api.Manga, authors, artists *api.AuthorList) MangaInfo {
+func convertManga(b *api.Manga, authorMap map[string]api.AuthorData) MangaInfo {
 	authorNames := make([]string, 0)
-	for _, a := range authors.Data {
-		authorNames = append(authorNames, a.Attributes.Name)
+	for _, id := range b.Data.Relationships.Author {
+		if a, ok := authorMap[id]; ok {
+			authorNames = append(authorNames, a.Attributes.Name)
+		}
 	}
 
 	artistNames := make([]string, 0)
-	for _, a := range artists.Data {
-		artistNames = append(artistNames, a.Attributes.Name)
+	for _, id := range b.Data.Relationships.Artist {
+		if a, ok := authorMap[id]; ok {
+			artistNames = append(artistNames, a.Attributes.Name)
+		}
+	}
+
+	tagNames := make([]string, 0)
+	for _, t := range b.Data.Attributes.Tags {
+		tagNames = append(tagNames, localized(t.Attributes.Name))
 	}
 
 	return MangaInfo{
-		Title:   first(b.Data.Attributes.Title),
-		Authors: authorNames,
-		Artists: artistNames,
-		ID:      b.Data.ID,
+		Title:       convertTitle(b.Data.Attributes.Title, b.Data.Attributes.AltTitles),
+		Titles:      convertTitles(b.Data.Attributes.Title, b.Data.Attributes.AltTitles),
+		Authors:     authorNames,
+		Artists:     artistNames,
+		Description: localized(b.Data.Attributes.Description),
+		Tags:        tagNames,
+		Year:        b.Data.Attributes.Year,
+		Status:      b.Data.Attributes.Status,
+		Demographic: b.Data.Attributes.PublicationDemographic,
+		Links:       b.Data.Attributes.Links,
+		ID:          b.Data.ID,
 	}
 }
 
@@ -56,6 +73,30 @@ func convertChapters(ca []api.ChapterData, groupMap map[string]api.GroupData) Ch
 	return sorted
 }
 
+// convertAggregate returns one chapter for every upload in the
+// aggregate, including alternate uploads of the same chapter.
+// Unnumbered chapters are folded into a single "Unknown" chapter.
+func convertAggregate(ag *api.Aggregate, lang language.Tag) ChapterList {
+	result := make(ChapterList, 0)
+	for _, vol := range ag.Volumes {
+		for _, chap := range vol.Chapters {
+			for _, id := range append([]string{chap.ID}, chap.Others...) {
+				result = append(result, Chapter{
+					Info: ChapterInfo{
+						ID:               id,
+						Language:         lang,
+						Identifier:       NewWithFallback(noneToEmpty(chap.Chapter), "Unknown"),
+						VolumeIdentifier: NewWithFallback(noneToEmpty(vol.Volume), "Special"),
+					},
+					Pages: make(map[int]image.Image),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
 func convertCovers(coverBaseURL string, mangaID string, co []api.CoverData) PathList {
 	result := make(PathList, 0)
 	for _, info := range co {
@@ -79,6 +120,7 @@ func convertChapter(ch *Chapter, ah *api.AtHome) PathList {
 		result = append(result, Path{
 			DataURL:           dataURL,
 			DataSaverURL:      dataSaverURL,
+			ChapterID:         ch.Info.ID,
 			ImageIdentifier:   i,
 			ChapterIdentifier: ch.Info.Identifier,
 			VolumeIdentifier:  ch.Info.VolumeIdentifier,
@@ -88,6 +130,15 @@ func convertChapter(ch *Chapter, ah *api.AtHome) PathList {
 	return result
 }
 
+// The aggregate endpoint uses "none" for missing identifiers.
+func noneToEmpty(s string) string {
+	if s == "none" {
+		return ""
+	}
+
+	return s
+}
+
 type multiple []string
 
 func (s multiple) String() string {
@@ -111,10 +162,49 @@ func reverse(v interface{}) {
 	}
 }
 
-func first(m map[string]string) string {
-	for _, val := range m {
+// localized returns the English variant of a localized string, or the
+// variant with the alphabetically first language code otherwise.
+func localized(l api.Localized) string {
+	if val, ok := l["en"]; ok {
 		return val
 	}
 
-	panic("empty map")
+	keys := make([]string, 0)
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return ""
+	}
+
+	return l[keys[0]]
+}
+
+// convertTitle prefers an English main or alternative title, falling
+// back to any main title.
+func convertTitle(title api.Localized, altTitles []api.Localized) string {
+	if val, ok := title["en"]; ok {
+		return val
+	}
+	for _, l := range altTitles {
+		if val, ok := l["en"]; ok {
+			return val
+		}
+	}
+
+	return localized(title)
+}
+
+// convertTitles collects the main and alternative titles by language,
+// with the main title always coming first.
+func convertTitles(title api.Localized, altTitles []api.Localized) map[string][]string {
+	titles := make(map[string][]string)
+	for _, l := range append([]api.Localized{title}, altTitles...) {
+		for lang, val := range l {
+			titles[lang] = append(titles[lang], val)
+		}
+	}
+
+	return titles
 }
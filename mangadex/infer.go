@@ -0,0 +1,73 @@
+package mangadex
+
+import "sort"
+
+// VolumeInference assigns volumes to chapters that were not published
+// with a volume number, leaving all other chapters untouched.
+type VolumeInference func(ChapterList) ChapterList
+
+// InferFromChapters assigns volumes using the volume numbers of other
+// chapters with the same identifier, such as those from the feeds of
+// other languages or the aggregate endpoint.
+func InferFromChapters(reference ChapterList) VolumeInference {
+	mapping := make(map[Identifier]Identifier)
+	for _, chap := range reference {
+		if !needsVolume(chap.Info) {
+			if _, ok := mapping[chap.Info.Identifier]; !ok {
+				mapping[chap.Info.Identifier] = chap.Info.VolumeIdentifier
+			}
+		}
+	}
+
+	return InferFromMapping(mapping)
+}
+
+// InferFromMapping assigns volumes using an explicit mapping from
+// chapter to volume identifiers.
+func InferFromMapping(mapping map[Identifier]Identifier) VolumeInference {
+	return func(cl ChapterList) ChapterList {
+		result := make(ChapterList, 0)
+		for _, chap := range cl {
+			if vol, ok := mapping[chap.Info.Identifier]; ok && needsVolume(chap.Info) {
+				chap.Info.VolumeIdentifier = vol
+			}
+			result = append(result, chap)
+		}
+
+		return result
+	}
+}
+
+// InferByChunking assigns volumes by grouping the remaining numbered
+// chapters of the reference into chunks of n chapters, starting with
+// the volume after the last known volume.  The reference should
+// contain all chapters of the manga, so that volumes do not depend on
+// which chapters have been selected.
+func InferByChunking(reference ChapterList, n int) VolumeInference {
+	last := NewIdentifier("0")
+	remaining := make([]Identifier, 0)
+	seen := make(map[Identifier]struct{})
+	for _, chap := range reference {
+		if vol := chap.Info.VolumeIdentifier; !vol.IsSpecial() && last.Less(vol) {
+			last = vol
+		}
+		if _, ok := seen[chap.Info.Identifier]; !ok && needsVolume(chap.Info) && !chap.Info.Identifier.IsSpecial() {
+			remaining = append(remaining, chap.Info.Identifier)
+			seen[chap.Info.Identifier] = struct{}{}
+		}
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].Less(remaining[j])
+	})
+
+	mapping := make(map[Identifier]Identifier)
+	for i, id := range remaining {
+		mapping[id] = Identifier{major: last.major + 1 + i/n}
+	}
+
+	return InferFromMapping(mapping)
+}
+
+func needsVolume(info ChapterInfo) bool {
+	return info.VolumeIdentifier.IsUnknown() || info.VolumeIdentifier.String() == "Special"
+}
@@ -98,7 +98,26 @@ func (c Chapter) Sorted() []image.Image {
 	return result
 }
 
-func (m Manga) WithChapters(chapters ChapterList) Manga {
+// WithTitleLanguage returns a copy of the manga titled in the given
+// language.  If no such title exists, the manga is returned unchanged
+// and false is reported.
+func (m Manga) WithTitleLanguage(lang string) (Manga, bool) {
+	if titles, ok := m.Info.Titles[lang]; ok && len(titles) > 0 {
+		m.Info.Title = titles[0]
+		return m, true
+	}
+
+	return m, false
+}
+
+// WithChapters returns a copy of the manga containing the given
+// chapters.  Chapters without a volume are assigned one by the first
+// of the given inferences that is able to.
+func (m Manga) WithChapters(chapters ChapterList, inferences ...VolumeInference) Manga {
+	for _, infer := range inferences {
+		chapters = infer(chapters)
+	}
+
 	vols := make(map[Identifier]Volume)
 	for _, chapter := range chapters {
 		chapID := chapter.Info.Identifier
@@ -162,6 +181,29 @@ func (m Manga) WithCovers(covers ImageList) Manga {
 	}
 }
 
+// WithNearestCovers returns a copy of the manga where volumes without
+// a cover use the cover of the closest volume, preferring earlier ones.
+func (m Manga) WithNearestCovers() Manga {
+	keys := m.Keys()
+	vols := make(map[Identifier]Volume)
+	for i, idx := range keys {
+		vol := m.Volumes[idx]
+		for offset := 1; vol.Cover == nil && offset < len(keys); offset++ {
+			if i-offset >= 0 && m.Volumes[keys[i-offset]].Cover != nil {
+				vol.Cover = m.Volumes[keys[i-offset]].Cover
+			} else if i+offset < len(keys) {
+				vol.Cover = m.Volumes[keys[i+offset]].Cover
+			}
+		}
+		vols[idx] = vol
+	}
+
+	return Manga{
+		Info:    m.Info,
+		Volumes: vols,
+	}
+}
+
 func cleanVolume(old Chapter) Volume {
 	chapters := make(map[Identifier]Chapter)
 	chapters[old.Info.Identifier] = cleanChapter(old)
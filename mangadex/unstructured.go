@@ -8,10 +8,17 @@ import (
 )
 
 type MangaInfo struct {
-	Title   string
-	Authors multiple
-	Artists multiple
-	ID      string
+	Title       string
+	Titles      map[string][]string
+	Authors     multiple
+	Artists     multiple
+	Description string
+	Tags        []string
+	Year        int
+	Status      string
+	Demographic string
+	Links       map[string]string
+	ID          string
 }
 
 type VolumeInfo struct {
@@ -34,6 +41,10 @@ type ChapterInfo struct {
 type Image struct {
 	Image image.Image
 
+	// encoded source, if available
+	Data   []byte
+	Format string
+
 	// identifiers
 	ImageIdentifier   int
 	ChapterIdentifier Identifier
@@ -43,6 +54,7 @@ type Image struct {
 type Path struct {
 	DataURL      string
 	DataSaverURL string
+	ChapterID    string
 
 	// identifiers
 	ImageIdentifier   int
@@ -51,8 +63,14 @@ type Path struct {
 }
 
 func (i Path) WithImage(img image.Image) Image {
+	return i.WithData(img, nil, "")
+}
+
+func (i Path) WithData(img image.Image, data []byte, format string) Image {
 	return Image{
 		Image:             img,
+		Data:              data,
+		Format:            format,
 		ChapterIdentifier: i.ChapterIdentifier,
 		VolumeIdentifier:  i.VolumeIdentifier,
 		ImageIdentifier:   i.ImageIdentifier,